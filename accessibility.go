@@ -0,0 +1,106 @@
+package tinysvg
+
+import (
+	"strings"
+)
+
+const (
+	// Directions for Nav
+	NavNext      = "next"
+	NavPrev      = "prev"
+	NavUp        = "up"
+	NavUpRight   = "up-right"
+	NavRight     = "right"
+	NavDownRight = "down-right"
+	NavDown      = "down"
+	NavDownLeft  = "down-left"
+	NavLeft      = "left"
+	NavUpLeft    = "up-left"
+)
+
+// idListAttrs are attributes where the value is a space separated list of ids, without "#"
+var idListAttrs = map[string]bool{"aria-labelledby": true, "aria-describedby": true}
+
+// Role sets the role attribute, like "img", "list" or "listitem"
+func (svg *Tag) Role(role string) {
+	svg.AddAttrib("role", []byte(role))
+}
+
+// AriaLabel sets the aria-label attribute.
+// Note that aria-* attributes are not part of SVG Tiny 1.2, so Validate will report them.
+func (svg *Tag) AriaLabel(label string) {
+	svg.AddAttrib("aria-label", escapeXML([]byte(label), true))
+}
+
+// addIDList adds the ids of the given tags to an attribute with a space separated list of ids.
+// The tags are given ids if they do not have one.
+func (svg *Tag) addIDList(attrName string, tags []*Tag) {
+	ids := strings.Fields(string(svg.Attrib(attrName)))
+	for _, t := range tags {
+		ids = append(ids, t.EnsureID())
+	}
+	svg.AddAttrib(attrName, []byte(strings.Join(ids, " ")))
+}
+
+// AriaLabelledBy adds the ids of the given tags to the aria-labelledby attribute
+func (svg *Tag) AriaLabelledBy(tags ...*Tag) {
+	svg.addIDList("aria-labelledby", tags)
+}
+
+// AriaDescribedBy adds the ids of the given tags to the aria-describedby attribute
+func (svg *Tag) AriaDescribedBy(tags ...*Tag) {
+	svg.addIDList("aria-describedby", tags)
+}
+
+// addDescriptive adds a title or desc element with the given text, which is escaped,
+// after the existing title and desc elements, before any other children
+func (svg *Tag) addDescriptive(name, text string) *Tag {
+	tag := NewTag([]byte(name))
+	tag.AddContent(escapeXML([]byte(text), false))
+	child := svg.firstChild
+	for child != nil && (!child.isElement() || string(child.name) == "title" || string(child.name) == "desc") {
+		child = child.nextSibling
+	}
+	svg.InsertBefore(tag, child)
+	return tag
+}
+
+// LabelledBy adds a title element with the given title, and refers to it from the aria-labelledby attribute.
+// Returns the title element, which is given an id.
+func (svg *Tag) LabelledBy(title string) *Tag {
+	t := svg.addDescriptive("title", title)
+	svg.AriaLabelledBy(t)
+	return t
+}
+
+// DescribedBy adds a desc element with the given description, and refers to it from the aria-describedby attribute.
+// Returns the desc element, which is given an id.
+func (svg *Tag) DescribedBy(description string) *Tag {
+	desc := svg.addDescriptive("desc", description)
+	svg.AriaDescribedBy(desc)
+	return desc
+}
+
+// Nav sets the nav-* attribute for the given direction, like "next" or "up-left", to refer to the given tag.
+// The tag is given an id if it does not have one. If the tag is nil, the attribute is set to "auto".
+func (svg *Tag) Nav(direction string, target *Tag) {
+	if target == nil {
+		svg.AddAttrib("nav-"+direction, []byte("auto"))
+		return
+	}
+	svg.AddAttrib("nav-"+direction, []byte("url(#"+target.EnsureID()+")"))
+}
+
+// FocusOrder makes the given tags focusable, and sets nav-next and nav-prev so that
+// the focus moves between them in the given order
+func FocusOrder(tags ...*Tag) {
+	for i, t := range tags {
+		t.Focusable(true, false)
+		if i > 0 {
+			t.Nav(NavPrev, tags[i-1])
+		}
+		if i < len(tags)-1 {
+			t.Nav(NavNext, tags[i+1])
+		}
+	}
+}
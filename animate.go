@@ -0,0 +1,184 @@
+package tinysvg
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	// Indefinite can be used for Dur, RepeatCount and RepeatDur, and for Begin and End
+	Indefinite = "indefinite"
+
+	// Values for Timing.Fill
+	Freeze = "freeze"
+	Remove = "remove"
+
+	// Values for Interpolation.CalcMode
+	CalcDiscrete = "discrete"
+	CalcLinear   = "linear"
+	CalcPaced    = "paced"
+	CalcSpline   = "spline"
+
+	// Values for the rotate argument to AnimateMotion
+	RotateAuto        = "auto"
+	RotateAutoReverse = "auto-reverse"
+)
+
+// Timing holds the timing attributes that all animation elements have.
+// Empty fields are left out.
+type Timing struct {
+	Begin       string // like "2s", "click", "anim1.end+0.5s" or "indefinite"
+	Dur         string // like "1s", "500ms" or "indefinite"
+	End         string
+	RepeatCount string // like "3" or "indefinite"
+	RepeatDur   string
+	Fill        string // "freeze" or "remove"
+	Restart     string // "always", "whenNotActive" or "never"
+}
+
+// Interpolation holds the attributes that decide which values an animation goes through,
+// and how they are combined with the underlying value. Empty fields are left out.
+type Interpolation struct {
+	From, To, By string
+	Values       []string     // rendered as "a;b;c", overrides From, To and By
+	KeyTimes     []float64    // rendered as "0;0.5;1"
+	KeySplines   [][4]float64 // rendered as "x1 y1 x2 y2;...", used together with CalcMode "spline"
+	CalcMode     string       // "discrete", "linear", "paced" or "spline"
+	Additive     string       // "replace" or "sum"
+	Accumulate   string       // "none" or "sum"
+}
+
+// addTiming adds the timing attributes to an animation element
+func (svg *Tag) addTiming(t *Timing) {
+	if t == nil {
+		return
+	}
+	for _, attr := range []struct{ name, value string }{
+		{"begin", t.Begin},
+		{"dur", t.Dur},
+		{"end", t.End},
+		{"repeatCount", t.RepeatCount},
+		{"repeatDur", t.RepeatDur},
+		{"fill", t.Fill},
+		{"restart", t.Restart},
+	} {
+		if attr.value != "" {
+			svg.AddAttrib(attr.name, []byte(attr.value))
+		}
+	}
+}
+
+// addInterpolation adds the value, calcMode and addition attributes to an animation element
+func (svg *Tag) addInterpolation(i *Interpolation) {
+	if i == nil {
+		return
+	}
+	if len(i.Values) > 0 {
+		svg.AddAttrib("values", []byte(strings.Join(i.Values, ";")))
+	} else {
+		for _, attr := range []struct{ name, value string }{{"from", i.From}, {"to", i.To}, {"by", i.By}} {
+			if attr.value != "" {
+				svg.AddAttrib(attr.name, []byte(attr.value))
+			}
+		}
+	}
+	if len(i.KeyTimes) > 0 {
+		var buf bytes.Buffer
+		for n, keyTime := range i.KeyTimes {
+			if n > 0 {
+				buf.WriteByte(';')
+			}
+			buf.Write(f2b(keyTime))
+		}
+		svg.AddAttrib("keyTimes", buf.Bytes())
+	}
+	if len(i.KeySplines) > 0 {
+		var buf bytes.Buffer
+		for n, spline := range i.KeySplines {
+			if n > 0 {
+				buf.WriteByte(';')
+			}
+			for m, x := range spline {
+				if m > 0 {
+					buf.WriteByte(' ')
+				}
+				buf.Write(f2b(x))
+			}
+		}
+		svg.AddAttrib("keySplines", buf.Bytes())
+	}
+	for _, attr := range []struct{ name, value string }{
+		{"calcMode", i.CalcMode},
+		{"additive", i.Additive},
+		{"accumulate", i.Accumulate},
+	} {
+		if attr.value != "" {
+			svg.AddAttrib(attr.name, []byte(attr.value))
+		}
+	}
+}
+
+// Animate adds an animate element, that animates the given attribute of this tag
+func (svg *Tag) Animate(attributeName string, t *Timing, i *Interpolation) *Tag {
+	anim := svg.AddNewTag([]byte("animate"))
+	anim.AddAttrib("attributeName", []byte(attributeName))
+	anim.addTiming(t)
+	anim.addInterpolation(i)
+	return anim
+}
+
+// AnimateSet adds a set element, that sets the given attribute of this tag to the given value
+func (svg *Tag) AnimateSet(attributeName, to string, t *Timing) *Tag {
+	anim := svg.AddNewTag([]byte("set"))
+	anim.AddAttrib("attributeName", []byte(attributeName))
+	anim.AddAttrib("to", []byte(to))
+	anim.addTiming(t)
+	return anim
+}
+
+// AnimateColor adds an animateColor element, that animates the given color attribute of this tag,
+// like "fill" or "stroke"
+func (svg *Tag) AnimateColor(attributeName string, t *Timing, i *Interpolation) *Tag {
+	anim := svg.AddNewTag([]byte("animateColor"))
+	anim.AddAttrib("attributeName", []byte(attributeName))
+	anim.addTiming(t)
+	anim.addInterpolation(i)
+	return anim
+}
+
+// AnimateTransform adds an animateTransform element, that animates the transform attribute of this tag.
+// transformType is "translate", "scale", "rotate", "skewX" or "skewY".
+func (svg *Tag) AnimateTransform(transformType string, t *Timing, i *Interpolation) *Tag {
+	anim := svg.AddNewTag([]byte("animateTransform"))
+	anim.AddAttribMap(map[string][]byte{
+		"attributeName": []byte("transform"),
+		"type":          []byte(transformType),
+	})
+	anim.addTiming(t)
+	anim.addInterpolation(i)
+	return anim
+}
+
+// AnimateMotion adds an animateMotion element, that moves this tag along the given path data.
+// rotate is "auto", "auto-reverse", an angle or "" for no rotation.
+func (svg *Tag) AnimateMotion(path, rotate string, t *Timing, i *Interpolation) *Tag {
+	anim := svg.AddNewTag([]byte("animateMotion"))
+	if path != "" {
+		anim.AddAttrib("path", []byte(path))
+	}
+	if rotate != "" {
+		anim.AddAttrib("rotate", []byte(rotate))
+	}
+	anim.addTiming(t)
+	anim.addInterpolation(i)
+	return anim
+}
+
+// AnimateMotionAlong adds an animateMotion element, that moves this tag along the given path element.
+// The path element is referred to with an mpath element, and is given an id if it does not have one.
+func (svg *Tag) AnimateMotionAlong(path *Tag, rotate string, t *Timing, i *Interpolation) *Tag {
+	anim := svg.AnimateMotion("", rotate, t, i)
+	mpath := anim.AddNewTag([]byte("mpath"))
+	mpath.AddAttrib("xlink:href", []byte("#"+path.EnsureID()))
+	return anim
+}
@@ -0,0 +1,244 @@
+package tinysvg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// colorAttributes are the attributes that have colors as values,
+// and that are normalized when rendering canonical XML.
+var colorAttributes = map[string]bool{
+	"fill":           true,
+	"stroke":         true,
+	"color":          true,
+	"stop-color":     true,
+	"solid-color":    true,
+	"viewport-fill":  true,
+	"flood-color":    true,
+	"lighting-color": true,
+}
+
+// numericAttributes are the attributes that have numbers, lengths, lists of numbers, transforms,
+// path data or clock values as values, and where the numbers are normalized when rendering canonical XML.
+// Other attributes, like id, references and text, are left as they are.
+var numericAttributes = map[string]bool{
+	"x": true, "y": true, "x1": true, "y1": true, "x2": true, "y2": true,
+	"cx": true, "cy": true, "r": true, "rx": true, "ry": true, "dx": true, "dy": true,
+	"width": true, "height": true, "rotate": true, "viewBox": true,
+	"d": true, "points": true, "pathLength": true, "transform": true, "gradientTransform": true,
+	"opacity": true, "fill-opacity": true, "stroke-opacity": true, "stop-opacity": true,
+	"solid-opacity": true, "viewport-fill-opacity": true, "audio-level": true,
+	"stroke-width": true, "stroke-miterlimit": true, "stroke-dashoffset": true, "stroke-dasharray": true,
+	"font-size": true, "font-weight": true, "line-increment": true, "offset": true,
+	"keyTimes": true, "keySplines": true, "keyPoints": true,
+	"dur": true, "repeatDur": true, "repeatCount": true, "min": true, "max": true,
+}
+
+// commandAttributes are the numeric attributes with path data, transforms or lists of points as values,
+// where letters are commands or transform names, and where whitespace and commas are optional or
+// interchangeable. Their values are written in a compact form when rendering canonical XML.
+var commandAttributes = map[string]bool{
+	"d": true, "path": true, "points": true, "transform": true, "gradientTransform": true,
+}
+
+// CanonicalBytes renders a tag and its children as canonical XML.
+// Attributes are sorted by name, numbers and colors are normalized in the attributes that
+// have numbers or colors as values, while other attribute values are kept as they are. All attribute
+// values are quoted with double quotes, CDATA sections are written as escaped text,
+// comments and DOCTYPE declarations are left out and optional whitespace is left out,
+// so that tags that are semantically equal are rendered the same way.
+func (tag *Tag) CanonicalBytes() []byte {
+	var buf bytes.Buffer
+	tag.writeCanonical(&buf)
+	return buf.Bytes()
+}
+
+// CanonicalBytes renders the image as canonical XML. See Tag.CanonicalBytes for details.
+// This can be used for hashing or comparing images.
+func (image *Document) CanonicalBytes() []byte {
+	return image.renderRoot().CanonicalBytes()
+}
+
+// WriteCanonicalTo writes the image as canonical XML to the given io.Writer.
+// See Tag.CanonicalBytes for details. Returns bytes written and possibly an error.
+func (image *Document) WriteCanonicalTo(w io.Writer) (int64, error) {
+	n, err := w.Write(image.CanonicalBytes())
+	return int64(n), err
+}
+
+func (tag *Tag) writeCanonical(buf *bytes.Buffer) {
+	switch tag.kind {
+	case CDATANode:
+		// CDATA sections are written as escaped text
+		buf.Write(escapeXML(tag.content, false))
+		return
+	case CommentNode, DoctypeNode:
+		return
+	case ProcInstNode:
+		buf.Write(tag.nodeBytes())
+		return
+	case TextNode:
+		if len(bytes.TrimSpace(tag.content)) > 0 {
+			buf.Write(tag.content)
+		}
+		return
+	}
+	if tag.isRoot() {
+		buf.Write(tag.name)
+		tag.writeCanonicalContent(buf)
+		return
+	}
+	buf.WriteByte('<')
+	buf.Write(tag.name)
+	for _, attr := range tag.Attribs() {
+		buf.WriteByte(' ')
+		buf.WriteString(attr.Name)
+		if attr.Value != nil {
+			buf.WriteString(`="`)
+			buf.WriteString(canonicalValue(attr.Name, attr.Value))
+			buf.WriteByte('"')
+		}
+	}
+	if len(bytes.TrimSpace(tag.content)) == 0 && !tag.hasCanonicalChildren() && len(bytes.TrimSpace(tag.lastContent)) == 0 {
+		buf.WriteString("/>")
+		return
+	}
+	buf.WriteByte('>')
+	tag.writeCanonicalContent(buf)
+	buf.Write(ltSlash)
+	buf.Write(tag.name)
+	buf.WriteByte('>')
+}
+
+// hasCanonicalChildren checks if a tag has children that are written in canonical XML
+func (tag *Tag) hasCanonicalChildren() bool {
+	for child := tag.firstChild; child != nil; child = child.nextSibling {
+		switch child.kind {
+		case CommentNode, DoctypeNode:
+		case TextNode:
+			if len(bytes.TrimSpace(child.content)) > 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// writeCanonicalContent writes the content and children of a tag, leaving out whitespace-only text
+func (tag *Tag) writeCanonicalContent(buf *bytes.Buffer) {
+	if len(bytes.TrimSpace(tag.content)) > 0 {
+		buf.Write(tag.content)
+	}
+	for child := tag.firstChild; child != nil; child = child.nextSibling {
+		child.writeCanonical(buf)
+	}
+	if len(bytes.TrimSpace(tag.lastContent)) > 0 {
+		buf.Write(tag.lastContent)
+	}
+}
+
+// canonicalValue normalizes whitespace, numbers and colors in the value of an attribute that
+// has numbers or colors as values. The values of other attributes are returned as they are.
+func canonicalValue(attrName string, value []byte) string {
+	if !numericAttributes[attrName] && !colorAttributes[attrName] {
+		return string(value)
+	}
+	if commandAttributes[attrName] {
+		return canonicalCommands(string(value))
+	}
+	s := canonicalNumbers(string(normalizeSpace(value)))
+	if colorAttributes[attrName] {
+		if c, err := ParseColor(s); err == nil {
+			return canonicalColor(c)
+		}
+	}
+	return s
+}
+
+// canonicalColor returns a color on the form "#rgb", "#rrggbb", "rgba(r,g,b,a)" or a lowercase color name.
+// The short form is used when it is the same color, so that "#ff0000" becomes "#f00".
+func canonicalColor(c *Color) string {
+	if len(c.N) != 0 {
+		return strings.ToLower(c.N)
+	}
+	if c.A == OPAQUE {
+		if c.R%17 == 0 && c.G%17 == 0 && c.B%17 == 0 {
+			return fmt.Sprintf("#%x%x%x", c.R/17, c.G/17, c.B/17)
+		}
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return "rgba(" + strconv.Itoa(c.R) + "," + strconv.Itoa(c.G) + "," + strconv.Itoa(c.B) + "," + strconv.FormatFloat(c.A, 'f', -1, 64) + ")"
+}
+
+// canonicalCommands formats path data, transforms and lists of points with numbers with as few digits
+// as possible, a single space between numbers and no space next to commands and parentheses, except for
+// a space between transforms. "M 10.0,20 L30.50 40" becomes "M10 20L30.5 40", and
+// "translate(1.0, -0) scale(2)" becomes "translate(1 0) scale(2)".
+func canonicalCommands(value string) string {
+	var buf bytes.Buffer
+	var last byte // the last byte that was written, or 0
+	for i := 0; i < len(value); {
+		c := value[i]
+		if n := numberLength(value[i:]); n > 0 {
+			if x, err := strconv.ParseFloat(value[i:i+n], 64); err == nil {
+				if x == 0 {
+					x = 0 // no negative zero
+				}
+				if last != 0 && last != '(' && !isLetter(last) {
+					buf.WriteByte(' ')
+				}
+				buf.WriteString(strconv.FormatFloat(x, 'f', -1, 64))
+				last = '0'
+				i += n
+				continue
+			}
+		}
+		switch {
+		case c == ' ' || c == ',' || c == '\t' || c == '\n' || c == '\r':
+		case isLetter(c) && last == ')':
+			buf.WriteByte(' ')
+			fallthrough
+		default:
+			buf.WriteByte(c)
+			last = c
+		}
+		i++
+	}
+	return buf.String()
+}
+
+// isLetter checks if the given byte is an ASCII letter
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// canonicalNumbers formats all numbers in the given attribute value with as few digits as possible,
+// so that "1.500000" becomes "1.5" and "2.0" becomes "2". Numbers that are part of a word, like "a1", are left as they are.
+func canonicalNumbers(value string) string {
+	var buf bytes.Buffer
+	afterWord := false
+	for i := 0; i < len(value); {
+		if !afterWord {
+			if n := numberLength(value[i:]); n > 0 {
+				if x, err := strconv.ParseFloat(value[i:i+n], 64); err == nil {
+					if x == 0 {
+						x = 0 // no negative zero
+					}
+					buf.WriteString(strconv.FormatFloat(x, 'f', -1, 64))
+					i += n
+					continue
+				}
+			}
+		}
+		c := value[i]
+		afterWord = c == '_' || c == '#' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		buf.WriteByte(c)
+		i++
+	}
+	return buf.String()
+}
@@ -0,0 +1,278 @@
+package tinysvg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DifferenceKind is the kind of a Difference: Added, Removed or Changed
+type DifferenceKind int
+
+const (
+	Added DifferenceKind = iota
+	Removed
+	Changed
+)
+
+// Difference is a difference between two trees of tags, as returned by Diff
+type Difference struct {
+	Kind DifferenceKind
+	// Path is the path to the element, attribute or text that differs, like "svg/g[2]/rect[1]@fill"
+	// or "svg/text[1]/text()". The number in brackets counts the tags with the same name, starting at 1.
+	Path string
+	// Old and New are the old and new values. For elements, they are the canonical XML.
+	Old, New []byte
+}
+
+// String returns the name of the kind of difference
+func (kind DifferenceKind) String() string {
+	switch kind {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+// String returns a description of the difference, like "changed svg/rect[1]@fill: red -> blue"
+func (d Difference) String() string {
+	switch d.Kind {
+	case Added:
+		return fmt.Sprintf("added %s: %s", d.Path, d.New)
+	case Removed:
+		return fmt.Sprintf("removed %s: %s", d.Path, d.Old)
+	default:
+		return fmt.Sprintf("changed %s: %s -> %s", d.Path, d.Old, d.New)
+	}
+}
+
+// Diff compares two trees of tags and returns the added, removed and changed
+// elements, attributes and text. The order of attributes and the amount of
+// whitespace does not matter, and numbers that differ by at most the given
+// tolerance are considered equal. CDATA sections are compared as text, while
+// comments, processing instructions and DOCTYPE declarations are not compared.
+func Diff(a, b *Tag, tolerance float64) []Difference {
+	var diffs []Difference
+	path := string(a.name)
+	if !bytes.Equal(a.name, b.name) {
+		return append(diffs,
+			Difference{Removed, path, a.CanonicalBytes(), nil},
+			Difference{Added, string(b.name), nil, b.CanonicalBytes()})
+	}
+	return diffTags(diffs, path, a, b, tolerance)
+}
+
+// DiffDocuments compares two images. See Diff for details.
+func DiffDocuments(a, b *Document, tolerance float64) []Difference {
+	return diffChildren(nil, "", a.root, b.root, tolerance)
+}
+
+// diffTags compares two tags with the same name, and their children
+func diffTags(diffs []Difference, path string, a, b *Tag, tolerance float64) []Difference {
+	// Attributes that are removed or changed
+	for _, attr := range a.Attribs() {
+		attrPath := path + "@" + attr.Name
+		if !b.HasAttrib(attr.Name) {
+			diffs = append(diffs, Difference{Removed, attrPath, attr.Value, nil})
+		} else if other := b.Attrib(attr.Name); !valuesEqual(attr.Value, other, tolerance) {
+			diffs = append(diffs, Difference{Changed, attrPath, attr.Value, other})
+		}
+	}
+	// Attributes that are added
+	for _, attr := range b.Attribs() {
+		if !a.HasAttrib(attr.Name) {
+			diffs = append(diffs, Difference{Added, path + "@" + attr.Name, nil, attr.Value})
+		}
+	}
+	// Text before, between and after the child elements
+	runsA, runsB := a.textRuns(), b.textRuns()
+	for i := 0; i < len(runsA) || i < len(runsB); i++ {
+		var runA, runB []byte
+		if i < len(runsA) {
+			runA = runsA[i]
+		}
+		if i < len(runsB) {
+			runB = runsB[i]
+		}
+		textPath := path + "/text()"
+		if i > 0 {
+			textPath += "[" + strconv.Itoa(i+1) + "]"
+		}
+		diffs = diffText(diffs, textPath, runA, runB)
+	}
+	return diffChildren(diffs, path, a, b, tolerance)
+}
+
+// textRuns returns the text of a tag that is before, between and after its child elements.
+// CDATA sections are included as escaped text, so that CDATA sections and escaped text compare equal.
+func (tag *Tag) textRuns() [][]byte {
+	runs := [][]byte{copyBytes(tag.content)}
+	for child := tag.firstChild; child != nil; child = child.nextSibling {
+		switch {
+		case child.isElement():
+			runs = append(runs, nil)
+		case child.kind == TextNode:
+			runs[len(runs)-1] = append(runs[len(runs)-1], child.content...)
+		case child.kind == CDATANode:
+			runs[len(runs)-1] = append(runs[len(runs)-1], escapeXML(child.content, false)...)
+		}
+	}
+	runs[len(runs)-1] = append(runs[len(runs)-1], tag.lastContent...)
+	return runs
+}
+
+// diffText compares two pieces of text, ignoring insignificant whitespace
+func diffText(diffs []Difference, path string, a, b []byte) []Difference {
+	na, nb := normalizeSpace(a), normalizeSpace(b)
+	switch {
+	case bytes.Equal(na, nb):
+		return diffs
+	case len(na) == 0:
+		return append(diffs, Difference{Added, path, nil, nb})
+	case len(nb) == 0:
+		return append(diffs, Difference{Removed, path, na, nil})
+	}
+	return append(diffs, Difference{Changed, path, na, nb})
+}
+
+// diffChildren compares the children of two tags. Children with the same name
+// are paired in the order they appear, so that the third rect in a is compared
+// with the third rect in b.
+func diffChildren(diffs []Difference, path string, a, b *Tag, tolerance float64) []Difference {
+	var names []string
+	childrenA := make(map[string][]*Tag)
+	childrenB := make(map[string][]*Tag)
+	for _, side := range []struct {
+		parent   *Tag
+		children map[string][]*Tag
+	}{{a, childrenA}, {b, childrenB}} {
+		for child := side.parent.firstChild; child != nil; child = child.nextSibling {
+			if !child.isElement() {
+				continue
+			}
+			name := string(child.name)
+			if _, seen := childrenA[name]; !seen {
+				if _, seen := childrenB[name]; !seen {
+					names = append(names, name)
+				}
+			}
+			side.children[name] = append(side.children[name], child)
+		}
+	}
+	prefix := ""
+	if path != "" {
+		prefix = path + "/"
+	}
+	for _, name := range names {
+		as, bs := childrenA[name], childrenB[name]
+		for i := 0; i < len(as) || i < len(bs); i++ {
+			childPath := prefix + name + "[" + strconv.Itoa(i+1) + "]"
+			if path == "" && len(as) <= 1 && len(bs) <= 1 {
+				childPath = name // for the top level svg tag
+			}
+			switch {
+			case i >= len(bs):
+				diffs = append(diffs, Difference{Removed, childPath, as[i].CanonicalBytes(), nil})
+			case i >= len(as):
+				diffs = append(diffs, Difference{Added, childPath, nil, bs[i].CanonicalBytes()})
+			default:
+				diffs = diffTags(diffs, childPath, as[i], bs[i], tolerance)
+			}
+		}
+	}
+	return diffs
+}
+
+// normalizeSpace trims the given text and replaces all runs of whitespace with a single space
+func normalizeSpace(text []byte) []byte {
+	return bytes.Join(bytes.Fields(text), space)
+}
+
+// valuesEqual compares two attribute values, where numbers may differ by the given tolerance
+func valuesEqual(a, b []byte, tolerance float64) bool {
+	if bytes.Equal(normalizeSpace(a), normalizeSpace(b)) {
+		return true
+	}
+	ta, tb := tokenize(string(a)), tokenize(string(b))
+	if len(ta) != len(tb) {
+		return false
+	}
+	for i := range ta {
+		if ta[i] == tb[i] {
+			continue
+		}
+		x, errA := strconv.ParseFloat(ta[i], 64)
+		y, errB := strconv.ParseFloat(tb[i], 64)
+		if errA != nil || errB != nil || math.Abs(x-y) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize splits an attribute value into numbers and other words,
+// skipping whitespace and commas. "M10,20 L 30.5 40px" becomes
+// "M", "10", "20", "L", "30.5", "40" and "px".
+func tokenize(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case strings.IndexByte(" \t\r\n,", c) != -1:
+			i++
+		case numberLength(s[i:]) > 0:
+			n := numberLength(s[i:])
+			tokens = append(tokens, s[i:i+n])
+			i += n
+		default:
+			start := i
+			for i < len(s) && strings.IndexByte(" \t\r\n,", s[i]) == -1 && (i == start || numberLength(s[i:]) == 0) {
+				i++
+			}
+			tokens = append(tokens, s[start:i])
+		}
+	}
+	return tokens
+}
+
+// numberLength returns the length of the number at the start of s, or 0 if s does not start with a number
+func numberLength(s string) int {
+	i := 0
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+	digits := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0
+	}
+	// Exponent
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '-' || s[j] == '+') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	return i
+}
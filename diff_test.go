@@ -0,0 +1,69 @@
+package tinysvg
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a, svgA := NewTinySVG(100, 100)
+	svgA.AddNewTag([]byte("g"))
+	g := svgA.AddNewTag([]byte("g"))
+	g.Box(0, 0, 10, 10, "red")
+	g.AddText(0, 0, 12, "serif", "Hello   world")
+	svgA.AddCircle(5, 5, 2)
+
+	b := a.Clone()
+	if diffs := DiffDocuments(a, b, 0); len(diffs) != 0 {
+		t.Fatalf("expected no differences, got %v\n", diffs)
+	}
+
+	rect, _ := b.QuerySelector("svg > g:nth-child(2) > rect")
+	rect.Fill("blue")
+	rect.AddAttrib("x", []byte("0.0000001"))
+	text, _ := b.QuerySelector("text")
+	text.content = []byte(" Hello world ")
+	circle, _ := b.QuerySelector("circle")
+	circle.Detach()
+	svgB, _ := b.GetTag([]byte("svg"))
+	svgB.AddNewTag([]byte("line"))
+
+	diffs := DiffDocuments(a, b, 0.001)
+	expected := []string{
+		"changed svg/g[2]/rect[1]@fill: red -> blue",
+		"removed svg/circle[1]: <circle cx=\"5\" cy=\"5\" r=\"2\"/>",
+		"added svg/line[1]: <line/>",
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d differences, got %v\n", len(expected), diffs)
+	}
+	for i, diff := range diffs {
+		if diff.String() != expected[i] {
+			t.Fatalf("expected %q, got %q\n", expected[i], diff.String())
+		}
+	}
+	if diffs[1].Path != "svg/circle[1]" {
+		t.Fatalf("unexpected path: %s\n", diffs[1].Path)
+	}
+}
+
+func TestValuesEqual(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"M 1.000000 2.000000 L 3 4", "M1,2 L3,4"},
+		{"10px", "10.0000px"},
+		{"translate(1 2)", "translate(1.0, 2)"},
+		{"1e2", "100"},
+	} {
+		if !valuesEqual([]byte(pair[0]), []byte(pair[1]), 1e-9) {
+			t.Fatalf("expected %q and %q to be equal\n", pair[0], pair[1])
+		}
+	}
+	for _, pair := range [][2]string{
+		{"M 1 2", "M 1 3"},
+		{"10px", "10em"},
+		{"red", "blue"},
+	} {
+		if valuesEqual([]byte(pair[0]), []byte(pair[1]), 1e-9) {
+			t.Fatalf("expected %q and %q to differ\n", pair[0], pair[1])
+		}
+	}
+}
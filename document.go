@@ -7,27 +7,80 @@ import (
 
 // Document is an XML document, with a title and a root tag
 type Document struct {
-	title []byte
-	root  *Tag
+	title        []byte
+	root         *Tag
+	idPrefix     string            // prefix for generated ids
+	idCounter    int               // counter for generated ids
+	strict       bool              // record errors for elements and attributes that are not in SVG Tiny 1.2
+	strictErrors []error           // errors recorded in strict mode
+	namespaces   map[string]string // registered namespaces, by prefix
 }
 
 // NewDocument creates a new XML/HTML/SVG image, with a root tag.
 // If rootTagName contains "<" or ">", it can be used for preceding declarations,
 // like <!DOCTYPE html> or <?xml version=\"1.0\"?>.
+// Declarations can also be added to the root tag as nodes, with AddDoctype, AddProcInst and AddComment.
 // Returns a pointer to a Document.
 func NewDocument(title, rootTagName []byte) *Document {
 	var image Document
 	image.title = title
 	rootTag := NewTag(rootTagName)
+	rootTag.owner = &image
 	image.root = rootTag
+	image.idPrefix = defaultIDPrefix
+	image.namespaces = make(map[string]string, len(defaultNamespaces))
+	for prefix, uri := range defaultNamespaces {
+		image.namespaces[prefix] = uri
+	}
 	return &image
 }
 
-// GetTag searches all tags for the given name
+// topElement returns the first element under the root tag, or nil
+func (image *Document) topElement() *Tag {
+	top := image.root.firstChild
+	for top != nil && !top.isElement() {
+		top = top.nextSibling
+	}
+	return top
+}
+
+// DeepCopy creates a copy of the image, with copies of all tags
+func (image *Document) DeepCopy() *Document {
+	nd := *image
+	nd.title = copyBytes(image.title)
+	nd.root = image.root.DeepCopy()
+	nd.root.owner = &nd
+	nd.strictErrors = append([]error{}, image.strictErrors...)
+	nd.namespaces = make(map[string]string, len(image.namespaces))
+	for prefix, uri := range image.namespaces {
+		nd.namespaces[prefix] = uri
+	}
+	return &nd
+}
+
+// Clone is the same as DeepCopy
+func (image *Document) Clone() *Document {
+	return image.DeepCopy()
+}
+
+// GetTag searches all tags for the given name.
+// See Tag.GetTag for how prefixed names are matched.
 func (image *Document) GetTag(name []byte) (*Tag, error) {
 	return image.root.GetTag(name)
 }
 
+// QuerySelector returns the first tag in the image that matches the given CSS selector.
+// Returns nil if no tag matches, and an error if the selector is invalid.
+func (image *Document) QuerySelector(selector string) (*Tag, error) {
+	return image.root.QuerySelector(selector)
+}
+
+// QuerySelectorAll returns all tags in the image that matches the given CSS selector.
+// Returns an error if the selector is invalid.
+func (image *Document) QuerySelectorAll(selector string) ([]*Tag, error) {
+	return image.root.QuerySelectorAll(selector)
+}
+
 // GetRoot returns the root tag of the image
 func (image *Document) GetRoot() *Tag {
 	return image.root
@@ -44,14 +97,17 @@ func (image *Document) AddContent(content []byte) (*Tag, error) {
 	return body, err
 }
 
-// Bytes renders the image as an XML document
+// Bytes renders the image as an XML document.
+// The title of the image is written as a title element, if the svg element has none, and registered
+// namespaces that are used, but not declared, are declared on the svg element. This is done in the
+// output, without changing the image.
 func (image *Document) Bytes() []byte {
-	return image.root.Bytes()
+	return image.renderRoot().Bytes()
 }
 
 // String renders the image as an XML document
 func (image *Document) String() string {
-	return image.root.String()
+	return image.renderRoot().String()
 }
 
 // SaveSVG will save the current image as an SVG file
@@ -63,5 +119,55 @@ func (image *Document) SaveSVG(filename string) error {
 // Returns bytes written and possibly an error.
 // This also fullfills the io.WriterTo interface.
 func (image *Document) WriteTo(w io.Writer) (int64, error) {
-	return image.root.WriteTo(w)
+	return image.renderRoot().WriteTo(w)
+}
+
+// renderRoot returns the root tag to render. If the title of the image or namespace declarations
+// need to be added to the svg element, a copy of the root tag and the svg element with the title
+// and declarations is returned, that shares the rest of the tags with the image, so that rendering
+// does not change the image.
+func (image *Document) renderRoot() *Tag {
+	top := image.topElement()
+	if top == nil {
+		return image.root
+	}
+	declare, _ := image.undeclaredNamespaces(top)
+	var title *Tag
+	if len(image.title) > 0 && string(top.name) == svgTag && image.titleElement() == nil {
+		title = NewTag([]byte("title"))
+		title.AddContent(escapeXML(image.title, false))
+	}
+	if len(declare) == 0 && title == nil {
+		return image.root
+	}
+	svg := top.ShallowCopy()
+	svg.attrs = make(map[string][]byte, len(top.attrs)+len(declare))
+	for name, value := range top.attrs {
+		svg.attrs[name] = value
+	}
+	for prefix, uri := range declare {
+		svg.attrs["xmlns:"+prefix] = []byte(uri)
+	}
+	if title != nil {
+		title.parent = svg
+		title.nextSibling = svg.firstChild
+		svg.firstChild = title
+	}
+	root := image.root.ShallowCopy()
+	root.firstChild = nil
+	var last *Tag
+	for child := image.root.firstChild; child != nil; child = child.nextSibling {
+		c := svg
+		if child != top {
+			c = child.ShallowCopy()
+		}
+		c.nextSibling = nil
+		if last == nil {
+			root.firstChild = c
+		} else {
+			last.nextSibling = c
+		}
+		last = c
+	}
+	return root
 }
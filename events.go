@@ -0,0 +1,81 @@
+package tinysvg
+
+const (
+	// Events that can be used with Handler and AddListener
+	EventClick     = "click"
+	EventActivate  = "activate"
+	EventFocusIn   = "focusin"
+	EventFocusOut  = "focusout"
+	EventMouseDown = "mousedown"
+	EventMouseUp   = "mouseup"
+	EventMouseOver = "mouseover"
+	EventMouseOut  = "mouseout"
+	EventMouseMove = "mousemove"
+	EventKeyDown   = "keydown"
+	EventKeyUp     = "keyup"
+	EventTextInput = "textInput"
+	EventLoad      = "load"
+	EventResize    = "resize"
+	EventScroll    = "scroll"
+	EventBegin     = "beginEvent"
+	EventEnd       = "endEvent"
+	EventRepeat    = "repeatEvent"
+)
+
+const scriptTypeECMA = "application/ecmascript"
+
+// listenerIDRefs are the attributes of ev:listener elements where the value is an id, without "#"
+var listenerIDRefs = map[string]bool{"observer": true, "target": true}
+
+// isIDRef checks if the value of the given attribute of a tag is an id, without "#",
+// like the observer attribute of an ev:listener element
+func (tag *Tag) isIDRef(attrName string) bool {
+	return listenerIDRefs[attrName] && tag.LocalName() == "listener"
+}
+
+// Script adds a script element with the given content type, like "application/ecmascript",
+// and the given code in a CDATA section. An empty content type is left out.
+func (svg *Tag) Script(contentType, code string) *Tag {
+	script := svg.AddNewTag([]byte("script"))
+	if contentType != "" {
+		script.AddAttrib("type", []byte(contentType))
+	}
+	script.AddCDATA(code)
+	return script
+}
+
+// Handler adds a handler element with the given ECMAScript code, wrapped in a CDATA section.
+// If event is not empty, the handler handles that event for this tag, by the ev:event attribute.
+// If event is empty, the handler can be used for events on other tags, with AddListener.
+// The handler element is given an id.
+func (svg *Tag) Handler(event, code string) *Tag {
+	handler := svg.AddNewTag([]byte("handler"))
+	handler.AddAttrib("type", []byte(scriptTypeECMA))
+	if event != "" {
+		handler.AddAttrib("ev:event", []byte(event))
+	}
+	handler.AddCDATA(code)
+	handler.EnsureID()
+	return handler
+}
+
+// AddListener adds an ev:listener element that makes the given handler element handle the given
+// event for this tag. Both this tag and the handler are given ids, if they do not have one.
+// The observer attribute is the id of this tag, while the handler attribute is a reference, like "#id".
+// The listener is added to the outermost svg element, after the other children.
+func (svg *Tag) AddListener(event string, handler *Tag) *Tag {
+	listener := NewTag([]byte("ev:listener"))
+	listener.AddAttribMap(map[string][]byte{
+		"event":    []byte(event),
+		"observer": []byte(svg.EnsureID()),
+		"handler":  []byte("#" + handler.EnsureID()),
+	})
+	parent := svg
+	for t := svg; t != nil && t.isElement(); t = t.parent {
+		if string(t.name) == svgTag {
+			parent = t
+		}
+	}
+	parent.AddTag(listener)
+	return listener
+}
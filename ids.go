@@ -0,0 +1,248 @@
+package tinysvg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const defaultIDPrefix = "id"
+
+// detachedIDCounter is used for generating ids for tags that are not part of a document
+var detachedIDCounter int64
+
+// ID returns the id of a tag, from either the "id" or the "xml:id" attribute.
+// Returns an empty string if no id is set.
+func (tag *Tag) ID() string {
+	if id, ok := tag.attrs["id"]; ok {
+		return string(id)
+	}
+	return string(tag.attrs["xml:id"])
+}
+
+// SetID sets the id of a tag
+func (tag *Tag) SetID(id string) {
+	if tag.HasAttrib("xml:id") {
+		tag.AddAttrib("xml:id", []byte(id))
+		return
+	}
+	tag.AddAttrib("id", []byte(id))
+}
+
+// Document returns the document a tag belongs to,
+// or nil if the tag is not part of a document.
+func (tag *Tag) Document() *Document {
+	root := tag
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root.owner
+}
+
+// EnsureID returns the id of a tag. If the tag has no id, a new unique id is generated and set.
+// If the tag is not part of a document, the generated id is only unique among detached tags.
+func (tag *Tag) EnsureID() string {
+	if id := tag.ID(); id != "" {
+		return id
+	}
+	if image := tag.Document(); image != nil {
+		return image.EnsureID(tag)
+	}
+	id := defaultIDPrefix + "-" + strconv.FormatInt(atomic.AddInt64(&detachedIDCounter, 1), 10)
+	tag.SetID(id)
+	return id
+}
+
+// SetIDPrefix sets the prefix that is used by NewID when generating ids.
+// The default prefix is "id".
+func (image *Document) SetIDPrefix(prefix string) {
+	image.idPrefix = prefix
+}
+
+// ids returns a map from all ids in the image to the first tag that uses each id
+func (image *Document) ids() map[string]*Tag {
+	found := make(map[string]*Tag)
+	it := image.root.Descendants()
+	for t := it.Next(); t != nil; t = it.Next() {
+		if id := t.ID(); id != "" {
+			if _, ok := found[id]; !ok {
+				found[id] = t
+			}
+		}
+	}
+	return found
+}
+
+// NewID generates a new id that is not already in use in the image,
+// like "id1", "id2" and so on, using the prefix given to SetIDPrefix.
+func (image *Document) NewID() string {
+	ids := image.ids()
+	for {
+		image.idCounter++
+		id := image.idPrefix + strconv.Itoa(image.idCounter)
+		if _, taken := ids[id]; !taken {
+			return id
+		}
+	}
+}
+
+// EnsureID returns the id of the given tag. If the tag has no id, a new unique id is generated and set.
+func (image *Document) EnsureID(tag *Tag) string {
+	if id := tag.ID(); id != "" {
+		return id
+	}
+	id := image.NewID()
+	tag.SetID(id)
+	return id
+}
+
+// GetElementByID returns the first tag with the given id, or an error if no tag has that id
+func (image *Document) GetElementByID(id string) (*Tag, error) {
+	it := image.root.Descendants()
+	for t := it.Next(); t != nil; t = it.Next() {
+		if t.ID() == id {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find id: %s", id)
+}
+
+// DuplicateIDs returns all ids that are used by more than one tag,
+// together with the tags that use them.
+func (image *Document) DuplicateIDs() map[string][]*Tag {
+	all := make(map[string][]*Tag)
+	it := image.root.Descendants()
+	for t := it.Next(); t != nil; t = it.Next() {
+		if id := t.ID(); id != "" {
+			all[id] = append(all[id], t)
+		}
+	}
+	duplicates := make(map[string][]*Tag)
+	for id, tags := range all {
+		if len(tags) > 1 {
+			duplicates[id] = tags
+		}
+	}
+	return duplicates
+}
+
+// referencedID returns the id in a reference on the form "#id" or "url(#id)"
+func referencedID(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "url(") && strings.HasSuffix(ref, ")") {
+		ref = strings.Trim(strings.TrimSpace(ref[4:len(ref)-1]), `"'`)
+	}
+	if len(ref) < 2 || ref[0] != '#' {
+		return "", false
+	}
+	return ref[1:], true
+}
+
+// Resolve returns the tag that is referred to by a reference on the form "#id" or "url(#id)"
+func (image *Document) Resolve(ref []byte) (*Tag, error) {
+	id, ok := referencedID(string(ref))
+	if !ok {
+		return nil, fmt.Errorf("not a local reference: %s", ref)
+	}
+	return image.GetElementByID(id)
+}
+
+// ResolveAttrib returns the tag that the given attribute of the given tag refers to,
+// for instance the target of a "xlink:href" attribute with the value "#id",
+// or the gradient in a "fill" attribute with the value "url(#id)".
+func (image *Document) ResolveAttrib(tag *Tag, attrName string) (*Tag, error) {
+	if !tag.HasAttrib(attrName) {
+		return nil, fmt.Errorf("no such attribute: %s", attrName)
+	}
+	return image.Resolve(tag.Attrib(attrName))
+}
+
+// rewriteReferences replaces all references to ids in the given attribute value of a tag,
+// using the given map from old to new ids. This covers "#id", "url(#id)",
+// animation timing values like "id.end+1s", lists of ids like in aria-labelledby
+// and plain ids like in the observer attribute of ev:listener elements.
+func (tag *Tag) rewriteReferences(attrName string, value []byte, renamed map[string]string) []byte {
+	s := string(value)
+	if idListAttrs[attrName] || tag.isIDRef(attrName) {
+		ids := strings.Fields(s)
+		for i, id := range ids {
+			if newID, ok := renamed[id]; ok {
+				ids[i] = newID
+			}
+		}
+		return []byte(strings.Join(ids, " "))
+	}
+	if id, ok := referencedID(s); ok && (strings.HasPrefix(strings.TrimSpace(s), "url(") || linkAttrs[attrName]) {
+		if newID, ok := renamed[id]; ok {
+			return []byte(strings.Replace(s, "#"+id, "#"+newID, 1))
+		}
+	}
+	if strings.Contains(s, "url(#") {
+		return []byte(rewriteURLReferences(s, renamed))
+	}
+	if attrName == "begin" || attrName == "end" {
+		parts := strings.Split(s, ";")
+		for i, part := range parts {
+			trimmed := strings.TrimSpace(part)
+			if dot := strings.IndexByte(trimmed, '.'); dot > 0 {
+				if newID, ok := renamed[trimmed[:dot]]; ok {
+					parts[i] = strings.Replace(part, trimmed[:dot]+".", newID+".", 1)
+				}
+			}
+		}
+		return []byte(strings.Join(parts, ";"))
+	}
+	return value
+}
+
+// rewriteURLReferences replaces the ids in all "url(#id)" references in the given value,
+// in a single pass, so that each id is looked up once, even if a new id is also an old id
+func rewriteURLReferences(s string, renamed map[string]string) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(s, "url(#")
+		if start < 0 {
+			break
+		}
+		start += len("url(#")
+		end := strings.IndexByte(s[start:], ')')
+		if end < 0 {
+			break
+		}
+		end += start
+		sb.WriteString(s[:start])
+		if newID, ok := renamed[s[start:end]]; ok {
+			sb.WriteString(newID)
+		} else {
+			sb.WriteString(s[start:end])
+		}
+		s = s[end:]
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+// PrefixIDs adds the given prefix to all ids in the image, and updates all
+// references to them. This is useful before merging several images into one.
+func (image *Document) PrefixIDs(prefix string) {
+	renamed := make(map[string]string)
+	for id := range image.ids() {
+		renamed[id] = prefix + id
+	}
+	if len(renamed) == 0 {
+		return
+	}
+	image.Walk(func(t *Tag, _ int) WalkAction {
+		for name, value := range t.attrs {
+			if name == "id" || name == "xml:id" {
+				if newID, ok := renamed[string(value)]; ok {
+					t.attrs[name] = []byte(newID)
+				}
+			} else if value != nil {
+				t.attrs[name] = t.rewriteReferences(name, value, renamed)
+			}
+		}
+		return WalkContinue
+	})
+}
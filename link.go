@@ -0,0 +1,43 @@
+package tinysvg
+
+const (
+	// Values for the target of a link
+	TargetReplace = "_replace"
+	TargetSelf    = "_self"
+	TargetParent  = "_parent"
+	TargetTop     = "_top"
+	TargetBlank   = "_blank"
+)
+
+// Link adds an a element with the given xlink:href. The xlink namespace is declared when the document is rendered.
+// Tags that are added to the returned a element become clickable links.
+func (svg *Tag) Link(href string) *Tag {
+	return svg.LinkTarget(href, "")
+}
+
+// LinkTarget adds an a element with the given xlink:href and target, like "_blank" for opening
+// the link in a new window. An empty target is left out.
+func (svg *Tag) LinkTarget(href, target string) *Tag {
+	a := NewTag([]byte("a"))
+	a.AddAttrib("xlink:href", escapeXML([]byte(href), true))
+	if target != "" {
+		a.AddAttrib("target", escapeXML([]byte(target), true))
+	}
+	svg.AddTag(a)
+	return a
+}
+
+// WrapInLink replaces this tag with an a element with the given xlink:href and target,
+// that contains this tag. Returns the a element, or ErrNoParent if this tag has no parent.
+func (svg *Tag) WrapInLink(href, target string) (*Tag, error) {
+	parent := svg.parent
+	if parent == nil {
+		return nil, ErrNoParent
+	}
+	a := parent.LinkTarget(href, target)
+	if err := svg.ReplaceWith(a); err != nil {
+		return nil, err
+	}
+	a.AddTag(svg)
+	return a, nil
+}
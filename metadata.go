@@ -0,0 +1,150 @@
+package tinysvg
+
+import (
+	"html"
+	"time"
+)
+
+const (
+	dateFormat     = "2006-01-02"
+	stillImageType = "http://purl.org/dc/dcmitype/StillImage"
+	svgMediaType   = "image/svg+xml"
+)
+
+// Metadata holds Dublin Core information about an image, that is rendered as RDF in a metadata element.
+// Empty fields are left out.
+type Metadata struct {
+	Title       string
+	Creator     string
+	Date        time.Time
+	License     string // the URL of the license, like "https://creativecommons.org/licenses/by/4.0/"
+	Description string
+	Keywords    []string
+}
+
+// Title adds a title element, with the given title. The title is escaped, like for SetTitle.
+func (svg *Tag) Title(title string) {
+	t := svg.AddNewTag([]byte("title"))
+	t.AddContent(escapeXML([]byte(title), false))
+}
+
+// addDC adds a Dublin Core element with the given text, if it is not empty
+func (svg *Tag) addDC(name, text string) *Tag {
+	if text == "" {
+		return nil
+	}
+	dc := svg.AddNewTag([]byte("dc:" + name))
+	dc.AddContent(escapeXML([]byte(text), false))
+	return dc
+}
+
+// AddMetadata adds a metadata element with the given information, as a cc:Work in an rdf:RDF element.
+// The rdf, dc and cc namespaces are declared in the output when the image is rendered.
+func (svg *Tag) AddMetadata(m *Metadata) *Tag {
+	metadata := svg.AddNewTag([]byte("metadata"))
+	work := metadata.AddNewTag([]byte("rdf:RDF")).AddNewTag([]byte("cc:Work"))
+	work.AddAttrib("rdf:about", []byte{})
+	work.addDC("format", svgMediaType)
+	work.AddNewTag([]byte("dc:type")).AddAttrib("rdf:resource", []byte(stillImageType))
+	work.addDC("title", m.Title)
+	if m.Creator != "" {
+		work.AddNewTag([]byte("dc:creator")).AddNewTag([]byte("cc:Agent")).addDC("title", m.Creator)
+	}
+	if !m.Date.IsZero() {
+		work.addDC("date", m.Date.Format(dateFormat))
+	}
+	work.addDC("description", m.Description)
+	if len(m.Keywords) > 0 {
+		bag := work.AddNewTag([]byte("dc:subject")).AddNewTag([]byte("rdf:Bag"))
+		for _, keyword := range m.Keywords {
+			bag.AddNewTag([]byte("rdf:li")).AddContent(escapeXML([]byte(keyword), false))
+		}
+	}
+	if m.License != "" {
+		work.AddNewTag([]byte("cc:license")).AddAttrib("rdf:resource", escapeXML([]byte(m.License), true))
+	}
+	return metadata
+}
+
+// textContent returns the unescaped text content of a tag, including the text nodes and
+// CDATA sections that are children of the tag
+func (tag *Tag) textContent() string {
+	text := html.UnescapeString(string(tag.content))
+	for child := tag.firstChild; child != nil; child = child.nextSibling {
+		switch child.kind {
+		case TextNode:
+			text += html.UnescapeString(string(child.content))
+		case CDATANode:
+			text += string(child.content)
+		}
+	}
+	return text + html.UnescapeString(string(tag.lastContent))
+}
+
+// Metadata reads the Dublin Core information from the first metadata element of the image that has a cc:Work,
+// regardless of which prefixes are used for the namespaces. Returns nil if there is none.
+func (image *Document) Metadata() *Metadata {
+	work, err := image.GetTagNS(ccNS, "Work")
+	if err != nil {
+		return nil
+	}
+	var m Metadata
+	for child := work.FirstChild(); child != nil; child = child.NextSibling() {
+		if !child.isElement() {
+			continue
+		}
+		switch child.NamespaceURI() + child.LocalName() {
+		case dcNS + "title":
+			m.Title = child.textContent()
+		case dcNS + "creator":
+			if agentTitle, err := child.GetTagNS(dcNS, "title"); err == nil {
+				m.Creator = agentTitle.textContent()
+			} else {
+				m.Creator = child.textContent()
+			}
+		case dcNS + "date":
+			m.Date, _ = time.Parse(dateFormat, child.textContent())
+		case dcNS + "description":
+			m.Description = child.textContent()
+		case dcNS + "subject":
+			child.Walk(func(t *Tag, depth int) WalkAction {
+				if t.LocalName() == "li" && t.NamespaceURI() == rdfNS {
+					m.Keywords = append(m.Keywords, t.textContent())
+				}
+				return WalkContinue
+			})
+		case ccNS + "license":
+			m.License = html.UnescapeString(string(child.AttribNS(rdfNS, "resource")))
+		}
+	}
+	return &m
+}
+
+// SetTitle sets the title of the image. For SVG images, the title is rendered as a title element,
+// as the first child of the svg element, unless the svg element already has a title element,
+// which is then given the new title. The title is escaped when rendered.
+func (image *Document) SetTitle(title string) {
+	image.title = []byte(title)
+	if t := image.titleElement(); t != nil {
+		t.content = escapeXML(image.title, false)
+	}
+}
+
+// Title returns the title of the image
+func (image *Document) Title() string {
+	return string(image.title)
+}
+
+// titleElement returns the first title element that is a child of the svg element, or nil
+func (image *Document) titleElement() *Tag {
+	svg := image.topElement()
+	if svg == nil || string(svg.name) != svgTag {
+		return nil
+	}
+	for child := svg.firstChild; child != nil; child = child.nextSibling {
+		if child.isElement() && string(child.name) == "title" {
+			return child
+		}
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package tinysvg
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FontMetrics holds the advance widths of the characters in a font, in font units
+type FontMetrics struct {
+	UnitsPerEm     int
+	Advances       map[rune]int
+	DefaultAdvance int // used for characters that are not in Advances
+}
+
+var (
+	ErrFontFormat = errors.New("not a TrueType or OpenType font")
+	ErrFontTable  = errors.New("missing or invalid font table")
+
+	// The advance widths of the printable ASCII characters, from space (32) to tilde (126),
+	// in the standard PostScript fonts Helvetica, Times-Roman and Courier.
+	sansSerifWidths = []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	serifWidths = []int{
+		250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278,
+		500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+		921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+		556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+		333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+		500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+	}
+
+	SansSerifMetrics = asciiMetrics(sansSerifWidths, 556)
+	SerifMetrics     = asciiMetrics(serifWidths, 500)
+	MonospaceMetrics = &FontMetrics{UnitsPerEm: 1000, Advances: map[rune]int{}, DefaultAdvance: 600}
+
+	// registered font metrics, by lowercase font family
+	fontMetrics   = map[string]*FontMetrics{}
+	fontMetricsMu sync.RWMutex
+)
+
+// asciiMetrics creates font metrics from a table of advance widths for the characters from 32 to 126
+func asciiMetrics(widths []int, defaultAdvance int) *FontMetrics {
+	m := &FontMetrics{UnitsPerEm: 1000, Advances: make(map[rune]int, len(widths)), DefaultAdvance: defaultAdvance}
+	for i, w := range widths {
+		m.Advances[rune(32+i)] = w
+	}
+	return m
+}
+
+// Advance returns the advance width of the given character, in font units.
+// Combining marks and other zero width characters have no advance.
+func (m *FontMetrics) Advance(r rune) int {
+	if w, ok := m.Advances[r]; ok {
+		return w
+	}
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) || unicode.IsControl(r) {
+		return 0
+	}
+	return m.DefaultAdvance
+}
+
+// Measure returns the width of the given string, for the given font size
+func (m *FontMetrics) Measure(s string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		units += m.Advance(r)
+	}
+	if m.UnitsPerEm <= 0 {
+		return 0
+	}
+	return float64(units) * size / float64(m.UnitsPerEm)
+}
+
+// RegisterFontMetrics makes the given metrics be used for the given font family, like "DejaVu Sans".
+// Font families are case insensitive.
+func RegisterFontMetrics(family string, m *FontMetrics) {
+	fontMetricsMu.Lock()
+	defer fontMetricsMu.Unlock()
+	fontMetrics[strings.ToLower(family)] = m
+}
+
+// LookupFontMetrics returns the metrics for a font family, which may be a comma separated list
+// like "'DejaVu Sans', Arial, sans-serif". Registered metrics are preferred. If none of the families
+// have been registered, the built-in approximations for sans-serif, serif or monospace are used.
+func LookupFontMetrics(family string) *FontMetrics {
+	var families []string
+	for _, name := range strings.Split(family, ",") {
+		families = append(families, strings.ToLower(strings.Trim(strings.TrimSpace(name), `"'`)))
+	}
+	fontMetricsMu.RLock()
+	for _, name := range families {
+		if m, ok := fontMetrics[name]; ok {
+			fontMetricsMu.RUnlock()
+			return m
+		}
+	}
+	fontMetricsMu.RUnlock()
+	for _, name := range families {
+		switch {
+		case strings.Contains(name, "mono") || strings.Contains(name, "courier") || strings.Contains(name, "consol"):
+			return MonospaceMetrics
+		case strings.Contains(name, "sans"):
+			return SansSerifMetrics
+		case strings.Contains(name, "serif") || strings.Contains(name, "times") || strings.Contains(name, "georgia"):
+			return SerifMetrics
+		}
+	}
+	return SansSerifMetrics
+}
+
+// MeasureText returns the width of the given text in user units, for the given font
+func MeasureText(font *Font, s string) float64 {
+	return LookupFontMetrics(font.Family).Measure(s, float64(font.Size))
+}
+
+// MeasureStyledText returns the width of the given text in user units, for the given font and style.
+// A fractional size in the style is used instead of the size of the font, and the letter spacing
+// of the style is added after each character.
+func MeasureStyledText(font *Font, style *FontStyle, s string) float64 {
+	if style == nil {
+		return MeasureText(font, s)
+	}
+	size := float64(font.Size)
+	if style.Size > 0 {
+		size = style.Size
+	}
+	return LookupFontMetrics(font.Family).Measure(s, size) + style.LetterSpacing*float64(utf8.RuneCountInString(s))
+}
+
+// WrapText breaks the given text into lines that are not wider than maxWidth, for the given font.
+// Lines are broken between words, and at newlines. Words that are wider than maxWidth are
+// placed on lines of their own.
+func WrapText(font *Font, s string, maxWidth float64) []string {
+	return WrapStyledText(font, nil, s, maxWidth)
+}
+
+// WrapStyledText is like WrapText, but measures the lines with MeasureStyledText,
+// so that a fractional size and the letter spacing of the given style are taken into account.
+func WrapStyledText(font *Font, style *FontStyle, s string, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line == "" {
+				line = word
+				continue
+			}
+			if MeasureStyledText(font, style, line+" "+word) > maxWidth {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			line += " " + word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// WrappedText adds a text element where the given message is wrapped to fit within maxWidth,
+// using MeasureText. Each line becomes a tspan element with x and dy attributes, with a line
+// height of 1.2 times the font size. Note that x and dy on tspan elements are not part of
+// SVG Tiny 1.2, so Validate will report them. TextArea can be used instead, for viewers that
+// support it.
+func (svg *Tag) WrappedText(p *Pos, f *Font, message string, maxWidth float64, c *Color) *Tag {
+	return svg.WrappedStyledText(p, f, nil, message, maxWidth, c)
+}
+
+// WrappedStyledText is like WrappedText, but sets the given style on the text element and wraps
+// the message with WrapStyledText. A fractional size in the style is also used for the line height.
+func (svg *Tag) WrappedStyledText(p *Pos, f *Font, style *FontStyle, message string, maxWidth float64, c *Color) *Tag {
+	text := svg.AddNewTag([]byte("text"))
+	text.AddAttribMap(map[string][]byte{
+		"x": f2b(p.X),
+		"y": f2b(p.Y),
+	})
+	text.addFont(f)
+	text.SetFontStyle(style)
+	text.Fill2(c)
+	size := float64(f.Size)
+	if style != nil && style.Size > 0 {
+		size = style.Size
+	}
+	for i, line := range WrapStyledText(f, style, message, maxWidth) {
+		tspan := text.AddNewTag([]byte("tspan"))
+		tspan.AddAttrib("x", f2b(p.X))
+		if i > 0 {
+			tspan.AddAttrib("dy", f2b(1.2*size))
+		}
+		tspan.AddContent([]byte(line))
+	}
+	return text
+}
+
+// LoadFontMetrics reads the metrics of a TrueType or OpenType font file
+func LoadFontMetrics(filename string) (*FontMetrics, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFontMetrics(data)
+}
+
+// fontData is the contents of a font file, with bounds checked reading of big endian numbers
+type fontData []byte
+
+func (d fontData) u16(offset int) (int, bool) {
+	if offset < 0 || offset+2 > len(d) {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint16(d[offset:])), true
+}
+
+func (d fontData) u32(offset int) (int, bool) {
+	if offset < 0 || offset+4 > len(d) {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint32(d[offset:])), true
+}
+
+// tables returns the offsets of the tables in a font, by tag
+func (d fontData) tables() (map[string]int, error) {
+	version, ok := d.u32(0)
+	if !ok || (version != 0x00010000 && version != 0x4f54544f && version != 0x74727565) { // 1.0, "OTTO" and "true"
+		return nil, ErrFontFormat
+	}
+	numTables, _ := d.u16(4)
+	tables := make(map[string]int, numTables)
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		offset, ok := d.u32(record + 8)
+		if !ok {
+			return nil, ErrFontFormat
+		}
+		tables[string(d[record:record+4])] = offset
+	}
+	return tables, nil
+}
+
+// ParseFontMetrics reads the metrics of a TrueType or OpenType font, from the head, hhea, hmtx and cmap tables.
+// Only the Unicode character maps of format 4 and 12 are supported.
+func ParseFontMetrics(data []byte) (*FontMetrics, error) {
+	d := fontData(data)
+	tables, err := d.tables()
+	if err != nil {
+		return nil, err
+	}
+	head, okHead := tables["head"]
+	hhea, okHhea := tables["hhea"]
+	hmtx, okHmtx := tables["hmtx"]
+	cmap, okCmap := tables["cmap"]
+	if !okHead || !okHhea || !okHmtx || !okCmap {
+		return nil, ErrFontTable
+	}
+	unitsPerEm, ok := d.u16(head + 18)
+	if !ok || unitsPerEm == 0 {
+		return nil, ErrFontTable
+	}
+	numberOfHMetrics, ok := d.u16(hhea + 34)
+	if !ok || numberOfHMetrics == 0 {
+		return nil, ErrFontTable
+	}
+	advance := func(glyph int) int {
+		if glyph >= numberOfHMetrics {
+			glyph = numberOfHMetrics - 1
+		}
+		w, _ := d.u16(hmtx + 4*glyph)
+		return w
+	}
+	glyphs, err := d.cmap(cmap)
+	if err != nil {
+		return nil, err
+	}
+	m := &FontMetrics{UnitsPerEm: unitsPerEm, Advances: make(map[rune]int, len(glyphs)), DefaultAdvance: advance(0)}
+	for r, glyph := range glyphs {
+		m.Advances[r] = advance(glyph)
+	}
+	return m, nil
+}
+
+// cmap reads the best Unicode subtable of the cmap table at the given offset, and returns the glyph index of each character
+func (d fontData) cmap(offset int) (map[rune]int, error) {
+	numSubtables, ok := d.u16(offset + 2)
+	if !ok {
+		return nil, ErrFontTable
+	}
+	best, bestScore := -1, 0
+	for i := 0; i < numSubtables; i++ {
+		record := offset + 4 + 8*i
+		platform, _ := d.u16(record)
+		encoding, _ := d.u16(record + 2)
+		subtable, ok := d.u32(record + 4)
+		if !ok {
+			return nil, ErrFontTable
+		}
+		format, _ := d.u16(offset + subtable)
+		score := 0
+		switch {
+		case format == 12 && (platform == 0 || (platform == 3 && encoding == 10)):
+			score = 2
+		case format == 4 && (platform == 0 || (platform == 3 && (encoding == 1 || encoding == 0))):
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = offset+subtable, score
+		}
+	}
+	switch bestScore {
+	case 2:
+		return d.cmap12(best)
+	case 1:
+		return d.cmap4(best)
+	}
+	return nil, ErrFontTable
+}
+
+// cmap4 reads a segment mapping subtable (format 4)
+func (d fontData) cmap4(offset int) (map[rune]int, error) {
+	segCountX2, ok := d.u16(offset + 6)
+	if !ok || offset+16+4*segCountX2 > len(d) {
+		return nil, ErrFontTable
+	}
+	segCount := segCountX2 / 2
+	endCodes := offset + 14
+	startCodes := endCodes + segCountX2 + 2
+	idDeltas := startCodes + segCountX2
+	idRangeOffsets := idDeltas + segCountX2
+	glyphs := make(map[rune]int)
+	for i := 0; i < segCount; i++ {
+		end, _ := d.u16(endCodes + 2*i)
+		start, _ := d.u16(startCodes + 2*i)
+		delta, _ := d.u16(idDeltas + 2*i)
+		rangeOffset, _ := d.u16(idRangeOffsets + 2*i)
+		for c := start; c <= end && c != 0xffff; c++ {
+			glyph := 0
+			if rangeOffset == 0 {
+				glyph = (c + delta) & 0xffff
+			} else if g, ok := d.u16(idRangeOffsets + 2*i + rangeOffset + 2*(c-start)); ok && g != 0 {
+				glyph = (g + delta) & 0xffff
+			}
+			if glyph != 0 {
+				glyphs[rune(c)] = glyph
+			}
+		}
+	}
+	return glyphs, nil
+}
+
+// cmap12 reads a segmented coverage subtable (format 12)
+func (d fontData) cmap12(offset int) (map[rune]int, error) {
+	numGroups, ok := d.u32(offset + 12)
+	if !ok || offset+16+12*numGroups > len(d) {
+		return nil, ErrFontTable
+	}
+	glyphs := make(map[rune]int)
+	for i := 0; i < numGroups; i++ {
+		group := offset + 16 + 12*i
+		start, _ := d.u32(group)
+		end, _ := d.u32(group + 4)
+		startGlyph, _ := d.u32(group + 8)
+		if end > unicode.MaxRune || start > end {
+			return nil, ErrFontTable
+		}
+		for c := start; c <= end; c++ {
+			glyphs[rune(c)] = startGlyph + c - start
+		}
+	}
+	return glyphs, nil
+}
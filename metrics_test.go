@@ -0,0 +1,102 @@
+package tinysvg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildFont creates a minimal TrueType font with a format 4 cmap, where 'A' and 'B' are glyph 1 and 2
+func buildFont() []byte {
+	u16 := func(buf *bytes.Buffer, values ...int) {
+		for _, v := range values {
+			binary.Write(buf, binary.BigEndian, uint16(v))
+		}
+	}
+	var head, hhea, hmtx, cmap bytes.Buffer
+	head.Write(make([]byte, 18))
+	u16(&head, 2048)
+	head.Write(make([]byte, 34))
+	hhea.Write(make([]byte, 34))
+	u16(&hhea, 2)
+	u16(&hmtx, 1000, 0, 1200, 0, 0)  // glyph 0 and 1, then a left side bearing for glyph 2
+	u16(&cmap, 0, 1, 3, 1, 0, 12)    // one subtable, platform 3, encoding 1, at offset 12
+	u16(&cmap, 4, 32, 0, 4, 2, 0, 0) // format 4, two segments
+	u16(&cmap, 'B', 0xffff, 0, 'A', 0xffff, 1-'A', 1, 0, 0)
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap.Bytes()}, {"head", head.Bytes()}, {"hhea", hhea.Bytes()}, {"hmtx", hmtx.Bytes()}}
+	var font bytes.Buffer
+	binary.Write(&font, binary.BigEndian, uint32(0x00010000))
+	u16(&font, len(tables), 0, 0, 0)
+	offset := 12 + 16*len(tables)
+	for _, table := range tables {
+		font.WriteString(table.tag)
+		binary.Write(&font, binary.BigEndian, []uint32{0, uint32(offset), uint32(len(table.data))})
+		offset += len(table.data)
+	}
+	for _, table := range tables {
+		font.Write(table.data)
+	}
+	return font.Bytes()
+}
+
+func TestFontMetrics(t *testing.T) {
+	if len(sansSerifWidths) != 95 || len(serifWidths) != 95 {
+		t.Fatal("the width tables should cover the characters from 32 to 126")
+	}
+	if w := MeasureText(&Font{"monospace", 10}, "abcd"); w != 24 {
+		t.Errorf("expected 24, got %v", w)
+	}
+	if w := MeasureText(&Font{"Arial, sans-serif", 10}, "Hi"); w != 9.44 {
+		t.Errorf("expected 9.44, got %v", w)
+	}
+	if LookupFontMetrics("'Times New Roman'") != SerifMetrics {
+		t.Error("expected the serif metrics for Times New Roman")
+	}
+
+	m, err := ParseFontMetrics(buildFont())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.UnitsPerEm != 2048 || m.Advance('A') != 1200 || m.Advance('B') != 1200 || m.Advance('C') != 1000 {
+		t.Errorf("wrong metrics: %+v", m)
+	}
+	RegisterFontMetrics("Test Font", m)
+	if w := MeasureText(&Font{"test font", 1024}, "AC"); w != 1100 {
+		t.Errorf("expected 1100, got %v", w)
+	}
+	if _, err := ParseFontMetrics([]byte("not a font")); err != ErrFontFormat {
+		t.Errorf("expected ErrFontFormat, got %v", err)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	f := &Font{"monospace", 10}
+	lines := WrapText(f, "the quick brown fox\njumps", 50)
+	expected := []string{"the", "quick", "brown", "fox", "jumps"}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, lines)
+	}
+	lines = WrapText(f, "aa bb cc dd", 30)
+	if len(lines) != 2 || lines[0] != "aa bb" || lines[1] != "cc dd" {
+		t.Errorf("got %q", lines)
+	}
+
+	_, svg := NewTinySVG(100, 100)
+	text := svg.WrappedText(&Pos{5, 20}, f, "aa bb cc dd", 30, ColorByName("black"))
+	if got := string(text.CanonicalBytes()); got != `<text fill="black" font-family="monospace" font-size="10" x="5" y="20"><tspan x="5">aa bb</tspan><tspan dy="12" x="5">cc dd</tspan></text>` {
+		t.Error(got)
+	}
+
+	style := &FontStyle{Size: 10.5, Unit: "px", LetterSpacing: 1}
+	lines = WrapStyledText(f, style, "aa bb cc dd", 30)
+	if len(lines) != 4 {
+		t.Fatalf("expected the letter spacing to break every word, got %q", lines)
+	}
+	text = svg.WrappedStyledText(&Pos{5, 20}, f, &FontStyle{Size: 5}, "aa bb cc dd", 30, ColorByName("black"))
+	if got := string(text.CanonicalBytes()); got != `<text fill="black" font-family="monospace" font-size="5" x="5" y="20"><tspan x="5">aa bb cc</tspan><tspan dy="6" x="5">dd</tspan></text>` {
+		t.Error(got)
+	}
+}
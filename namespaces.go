@@ -0,0 +1,224 @@
+package tinysvg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+	evNS         = "http://www.w3.org/2001/xml-events"
+	rdfNS        = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	dcNS         = "http://purl.org/dc/elements/1.1/"
+	ccNS         = "http://creativecommons.org/ns#"
+)
+
+var (
+	ErrUnknownNamespace = errors.New("no prefix is registered for the namespace")
+
+	// defaultNamespaces are the namespaces that are registered for new documents, by prefix
+	defaultNamespaces = map[string]string{
+		"xlink": xlinkNS,
+		"ev":    evNS,
+		"rdf":   rdfNS,
+		"dc":    dcNS,
+		"cc":    ccNS,
+	}
+)
+
+// RegisterNamespace registers a namespace prefix, like "dc" for "http://purl.org/dc/elements/1.1/".
+// Prefixes that are registered are declared on the svg element when the image is rendered,
+// if they are used by any element or attribute. "xlink", "ev", "rdf", "dc" and "cc" are registered by default.
+func (image *Document) RegisterNamespace(prefix, uri string) {
+	if image.namespaces == nil {
+		image.namespaces = make(map[string]string)
+	}
+	image.namespaces[prefix] = uri
+}
+
+// NamespaceURI returns the namespace that is registered for the given prefix, or "" if there is none
+func (image *Document) NamespaceURI(prefix string) string {
+	if prefix == "xml" {
+		return xmlNamespace
+	}
+	return image.namespaces[prefix]
+}
+
+// NamespacePrefix returns a prefix that is registered for the given namespace, or "" if there is none.
+// If several prefixes are registered for the namespace, the first one in alphabetical order is returned.
+func (image *Document) NamespacePrefix(uri string) string {
+	if uri == xmlNamespace {
+		return "xml"
+	}
+	var prefixes []string
+	for prefix, u := range image.namespaces {
+		if u == uri {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return ""
+	}
+	sort.Strings(prefixes)
+	return prefixes[0]
+}
+
+// namespaceDocument returns the document that a tag belongs to, for looking up registered namespaces,
+// or a document with the default namespaces if the tag is not part of a document
+func (tag *Tag) namespaceDocument() *Document {
+	if image := tag.Document(); image != nil {
+		return image
+	}
+	return &Document{namespaces: defaultNamespaces}
+}
+
+// LookupNamespace returns the namespace of a prefix, as declared by the xmlns attributes of this tag
+// and its ancestors. An empty prefix gives the default namespace. If the prefix is not declared,
+// the namespace that is registered for the prefix in the document is returned, or "" if there is none.
+func (tag *Tag) LookupNamespace(prefix string) string {
+	if prefix == "xml" {
+		return xmlNamespace
+	}
+	attrName := "xmlns"
+	if prefix != "" {
+		attrName += ":" + prefix
+	}
+	for t := tag; t != nil && t.isElement(); t = t.parent {
+		if t.HasAttrib(attrName) {
+			return string(t.Attrib(attrName))
+		}
+	}
+	if prefix == "" {
+		return ""
+	}
+	return tag.namespaceDocument().NamespaceURI(prefix)
+}
+
+// LocalName returns the name of a tag without the namespace prefix, like "RDF" for "rdf:RDF"
+func (tag *Tag) LocalName() string {
+	name := string(tag.name)
+	if prefix := namePrefix(name); prefix != "" {
+		return name[len(prefix)+1:]
+	}
+	return name
+}
+
+// NamespaceURI returns the namespace of a tag, like "http://www.w3.org/2000/svg" for an svg tag
+func (tag *Tag) NamespaceURI() string {
+	return tag.LookupNamespace(namePrefix(string(tag.name)))
+}
+
+// GetTagNS finds the first tag with the given namespace and local name, regardless of the prefix
+// that is used for the namespace. Returns an error if not found.
+func (tag *Tag) GetTagNS(uri, local string) (*Tag, error) {
+	var found *Tag
+	tag.Walk(func(t *Tag, depth int) WalkAction {
+		if t.isElement() && t.LocalName() == local && t.NamespaceURI() == uri {
+			found = t
+			return WalkStop
+		}
+		return WalkContinue
+	})
+	if found == nil {
+		return nil, fmt.Errorf("could not find tag: {%s}%s", uri, local)
+	}
+	return found, nil
+}
+
+// GetTagNS finds the first tag in the image with the given namespace and local name
+func (image *Document) GetTagNS(uri, local string) (*Tag, error) {
+	return image.root.GetTagNS(uri, local)
+}
+
+// AttribNS returns the value of the attribute with the given namespace and local name,
+// regardless of the prefix that is used for the namespace. Returns nil if it is not set.
+func (tag *Tag) AttribNS(uri, local string) []byte {
+	for _, attr := range tag.Attribs() {
+		prefix := namePrefix(attr.Name)
+		if prefix != "" && prefix != "xmlns" && attr.Name[len(prefix)+1:] == local && tag.LookupNamespace(prefix) == uri {
+			return attr.Value
+		}
+	}
+	return nil
+}
+
+// AddAttribNS adds an attribute with the given namespace and local name, like "xlink:href" for
+// "http://www.w3.org/1999/xlink" and "href". The prefix is the one that is declared for the
+// namespace by this tag or its ancestors, or else the one that is registered in the document.
+// Returns ErrUnknownNamespace if there is no prefix for the namespace.
+func (tag *Tag) AddAttribNS(uri, local string, value []byte) error {
+	prefix := ""
+	for t := tag; t != nil && t.isElement() && prefix == ""; t = t.parent {
+		for _, attr := range t.Attribs() {
+			if strings.HasPrefix(attr.Name, "xmlns:") && string(attr.Value) == uri {
+				prefix = strings.TrimPrefix(attr.Name, "xmlns:")
+				break
+			}
+		}
+	}
+	if prefix == "" {
+		prefix = tag.namespaceDocument().NamespacePrefix(uri)
+	}
+	if prefix == "" {
+		return ErrUnknownNamespace
+	}
+	tag.AddAttrib(prefix+":"+local, value)
+	return nil
+}
+
+// DeclareNamespaces declares the registered namespace prefixes that are used by the elements and
+// attributes of the image, but not declared, on the svg element. The declarations are also written
+// when the image is rendered, without changing the image, so this is only needed for rendering the
+// tags directly. Returns the prefixes that are used without being declared or registered.
+func (image *Document) DeclareNamespaces() []string {
+	top := image.topElement()
+	if top == nil {
+		return nil
+	}
+	declare, unknown := image.undeclaredNamespaces(top)
+	for prefix, uri := range declare {
+		top.AddAttrib("xmlns:"+prefix, []byte(uri))
+	}
+	return unknown
+}
+
+// undeclaredNamespaces finds the namespace prefixes that are used by the given tag and its descendants
+// without being declared. Returns the registered namespaces of the prefixes, and the sorted prefixes
+// that are not registered. The tags are not changed.
+func (image *Document) undeclaredNamespaces(top *Tag) (map[string]string, []string) {
+	declare := make(map[string]string)
+	unknown := make(map[string]bool)
+	check := func(t *Tag, prefix string) {
+		if prefix == "" || prefix == "xml" || prefix == "xmlns" || declare[prefix] != "" || unknown[prefix] {
+			return
+		}
+		for a := t; a != nil && a.isElement(); a = a.parent {
+			if a.HasAttrib("xmlns:" + prefix) {
+				return
+			}
+		}
+		if uri := image.NamespaceURI(prefix); uri != "" {
+			declare[prefix] = uri
+		} else {
+			unknown[prefix] = true
+		}
+	}
+	top.Walk(func(t *Tag, depth int) WalkAction {
+		if !t.isElement() {
+			return WalkSkipChildren
+		}
+		check(t, namePrefix(string(t.name)))
+		for attrName := range t.attrs {
+			check(t, namePrefix(attrName))
+		}
+		return WalkContinue
+	})
+	var prefixes []string
+	for prefix := range unknown {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return declare, prefixes
+}
@@ -0,0 +1,188 @@
+package tinysvg
+
+import (
+	"bytes"
+)
+
+// NodeKind is the kind of a node in the tree of tags
+type NodeKind int
+
+const (
+	// ElementNode is a regular element, like <rect/>, or the root tag of a document
+	ElementNode NodeKind = iota
+	// CDATANode is a CDATA section, like <![CDATA[...]]>. The content is the unescaped text.
+	CDATANode
+	// CommentNode is a comment, like <!-- ... -->. The content is the text of the comment.
+	CommentNode
+	// ProcInstNode is a processing instruction, like <?xml-stylesheet href="style.css"?>.
+	// The name is the target, and the content is the instruction.
+	ProcInstNode
+	// DoctypeNode is a DOCTYPE declaration, like <!DOCTYPE svg PUBLIC "...">.
+	// The content is what comes after "<!DOCTYPE ".
+	DoctypeNode
+	// TextNode is text between the children of an element. The content is the escaped text.
+	TextNode
+)
+
+var (
+	cdataStart      = []byte("<![CDATA[")
+	cdataEnd        = []byte("]]>")
+	cdataEndEscaped = []byte("]]]]><![CDATA[>")
+	commentStart    = []byte("<!--")
+	commentEnd      = []byte("-->")
+	doubleDash      = []byte("--")
+	spacedDash      = []byte("- -")
+	procInstStart   = []byte("<?")
+	procInstEnd     = []byte("?>")
+	doctypeStart    = []byte("<!DOCTYPE ")
+)
+
+// String returns the name of a kind of node, like "element" or "comment"
+func (kind NodeKind) String() string {
+	switch kind {
+	case ElementNode:
+		return "element"
+	case CDATANode:
+		return "cdata"
+	case CommentNode:
+		return "comment"
+	case ProcInstNode:
+		return "processing instruction"
+	case DoctypeNode:
+		return "doctype"
+	case TextNode:
+		return "text"
+	}
+	return "unknown"
+}
+
+// newNode creates a node that is not an element
+func newNode(kind NodeKind, name, content string) *Tag {
+	node := NewTag([]byte(name))
+	node.kind = kind
+	node.content = []byte(content)
+	return node
+}
+
+// newTextNode creates a text node, with text that is already escaped
+func newTextNode(escaped []byte) *Tag {
+	return newNode(TextNode, "#text", string(escaped))
+}
+
+// NewTextNode creates a new text node, with the given text. The text is escaped when rendered.
+func NewTextNode(text string) *Tag {
+	return newTextNode(escapeXML([]byte(text), false))
+}
+
+// NewCDATA creates a new CDATA section node, with the given text
+func NewCDATA(text string) *Tag {
+	return newNode(CDATANode, "#cdata-section", text)
+}
+
+// NewComment creates a new comment node, with the given text.
+// Any "--" in the text is rendered as "- -", since it is not allowed in comments.
+func NewComment(text string) *Tag {
+	return newNode(CommentNode, "#comment", text)
+}
+
+// NewProcInst creates a new processing instruction node, like NewProcInst("xml-stylesheet", `href="style.css"`)
+func NewProcInst(target, instruction string) *Tag {
+	return newNode(ProcInstNode, target, instruction)
+}
+
+// NewDoctype creates a new DOCTYPE declaration node, with what comes after "<!DOCTYPE ",
+// like `svg PUBLIC "-//W3C//DTD SVG 1.1 Tiny//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11-tiny.dtd"`
+func NewDoctype(declaration string) *Tag {
+	return newNode(DoctypeNode, "#doctype", declaration)
+}
+
+// AddTextNode adds a text node with the given text to a tag, after the other children.
+// Unlike AddContent, which places text before the children, this keeps the text in place
+// between children, like in <text>Hello <tspan>world</tspan>!</text>.
+func (tag *Tag) AddTextNode(text string) *Tag {
+	node := NewTextNode(text)
+	tag.AddChild(node)
+	return node
+}
+
+// AddCDATA adds a CDATA section with the given text to a tag. The text does not
+// need to be escaped, and any "]]>" is split over two CDATA sections when rendered.
+func (tag *Tag) AddCDATA(text string) *Tag {
+	node := NewCDATA(text)
+	tag.AddChild(node)
+	return node
+}
+
+// AddComment adds a comment with the given text to a tag
+func (tag *Tag) AddComment(text string) *Tag {
+	node := NewComment(text)
+	tag.AddChild(node)
+	return node
+}
+
+// AddProcInst adds a processing instruction to a tag
+func (tag *Tag) AddProcInst(target, instruction string) *Tag {
+	node := NewProcInst(target, instruction)
+	tag.AddChild(node)
+	return node
+}
+
+// AddDoctype adds a DOCTYPE declaration to a tag. This is normally added to
+// the root tag of a document, before the svg element.
+func (tag *Tag) AddDoctype(declaration string) *Tag {
+	node := NewDoctype(declaration)
+	tag.AddChild(node)
+	return node
+}
+
+// Kind returns the kind of node of a tag, like ElementNode or CommentNode
+func (tag *Tag) Kind() NodeKind {
+	return tag.kind
+}
+
+// cdata wraps text in a CDATA section. Any "]]>" in the text is split over two CDATA sections.
+func cdata(text string) []byte {
+	ret := append([]byte{}, cdataStart...)
+	ret = append(ret, bytes.Replace([]byte(text), cdataEnd, cdataEndEscaped, -1)...)
+	return append(ret, cdataEnd...)
+}
+
+// commentText returns text that is safe to use in a comment, where "--" is not allowed
+// and the text can not end with "-"
+func commentText(text []byte) []byte {
+	for bytes.Contains(text, doubleDash) {
+		text = bytes.Replace(text, doubleDash, spacedDash, -1)
+	}
+	if bytes.HasSuffix(text, []byte{'-'}) {
+		text = append(copyBytes(text), ' ')
+	}
+	return text
+}
+
+// nodeBytes renders a node that is not an element
+func (tag *Tag) nodeBytes() []byte {
+	var buf bytes.Buffer
+	switch tag.kind {
+	case CDATANode:
+		buf.Write(cdata(string(tag.content)))
+	case CommentNode:
+		buf.Write(commentStart)
+		buf.Write(commentText(tag.content))
+		buf.Write(commentEnd)
+	case ProcInstNode:
+		buf.Write(procInstStart)
+		buf.Write(tag.name)
+		if len(tag.content) > 0 {
+			buf.WriteByte(' ')
+			buf.Write(tag.content)
+		}
+		buf.Write(procInstEnd)
+	case DoctypeNode:
+		buf.Write(doctypeStart)
+		buf.Write(tag.content)
+		buf.WriteByte('>')
+	case TextNode:
+		buf.Write(tag.content)
+	}
+	return buf.Bytes()
+}
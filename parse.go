@@ -0,0 +1,141 @@
+package tinysvg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Parse parses an SVG or XML document, and returns a Document.
+// The XML declaration, if present, becomes the root tag of the document,
+// just like for images created with NewTinySVG.
+// Comments, CDATA sections, processing instructions and DOCTYPE declarations
+// become nodes of the corresponding kind, wherever they are placed.
+// Text before the first child of an element becomes the content of the element,
+// while text after a child becomes a text node, so that mixed content keeps its order.
+// Namespace prefixes that are declared with xmlns attributes are registered in the document,
+// unless the prefix is already registered for another namespace.
+func Parse(data []byte) (*Document, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var (
+		image *Document
+		stack []*Tag
+	)
+	// ensureDocument creates the document, if the first token is not the XML declaration
+	ensureDocument := func() {
+		if image == nil {
+			image = NewDocument([]byte{}, []byte{})
+			stack = append(stack, image.root)
+		}
+	}
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.ProcInst:
+			if token.Target == "xml" && image == nil {
+				image = NewDocument([]byte{}, []byte("<?xml "+string(token.Inst)+"?>"))
+				stack = append(stack, image.root)
+				continue
+			}
+			ensureDocument()
+			stack[len(stack)-1].AddProcInst(token.Target, string(token.Inst))
+		case xml.Comment:
+			ensureDocument()
+			stack[len(stack)-1].AddComment(string(token))
+		case xml.Directive:
+			ensureDocument()
+			if declaration := bytes.TrimPrefix(token, []byte("DOCTYPE")); len(declaration) < len(token) {
+				stack[len(stack)-1].AddDoctype(string(bytes.TrimSpace(declaration)))
+			}
+		case xml.StartElement:
+			ensureDocument()
+			if len(stack) == 1 && image.topElement() != nil {
+				return nil, fmt.Errorf("more than one root element: %s", qualifiedName(token.Name))
+			}
+			tag := NewTag([]byte(qualifiedName(token.Name)))
+			for _, attr := range token.Attr {
+				tag.attrs[qualifiedName(attr.Name)] = escapeXML([]byte(attr.Value), true)
+				if attr.Name.Space == "xmlns" && image.NamespaceURI(attr.Name.Local) == "" {
+					image.RegisterNamespace(attr.Name.Local, attr.Value)
+				}
+			}
+			stack[len(stack)-1].AddChild(tag)
+			stack = append(stack, tag)
+		case xml.EndElement:
+			if len(stack) < 2 {
+				return nil, fmt.Errorf("unexpected end element: %s", qualifiedName(token.Name))
+			}
+			current := stack[len(stack)-1]
+			if name := qualifiedName(token.Name); name != string(current.name) {
+				return nil, fmt.Errorf("element <%s> closed by </%s>", current.name, name)
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if bytes.HasPrefix(data[offset:], cdataStart) {
+				if len(stack) < 2 {
+					return nil, fmt.Errorf("unexpected CDATA section outside of the root element")
+				}
+				stack[len(stack)-1].AddCDATA(string(token))
+				continue
+			}
+			if len(stack) < 2 {
+				// Only whitespace is allowed outside of the root element
+				if len(bytes.TrimSpace(token)) > 0 {
+					return nil, fmt.Errorf("unexpected text outside of the root element: %q", token)
+				}
+				continue
+			}
+			current := stack[len(stack)-1]
+			if current.firstChild == nil {
+				current.AddContent(escapeXML(token, false))
+			} else {
+				current.AddChild(newTextNode(escapeXML(token, false)))
+			}
+		}
+	}
+	if image == nil || image.topElement() == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("unclosed element: %s", stack[len(stack)-1].name)
+	}
+	return image, nil
+}
+
+// qualifiedName returns a name on the form "prefix:local", or just "local" if there is no prefix
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// escapeXML escapes &, < and >, and also " if the text is an attribute value
+func escapeXML(text []byte, attr bool) []byte {
+	var buf bytes.Buffer
+	for _, c := range text {
+		switch {
+		case c == '&':
+			buf.WriteString("&amp;")
+		case c == '<':
+			buf.WriteString("&lt;")
+		case c == '>':
+			buf.WriteString("&gt;")
+		case c == '"' && attr:
+			buf.WriteString("&quot;")
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,488 @@
+package tinysvg
+
+// A small CSS selector engine, for finding tags in a tree of tags.
+//
+// Supported selectors:
+//   - type selectors, like "rect", and the universal selector "*"
+//   - namespaced type selectors, like "rdf|RDF", for matching "rdf:RDF"
+//   - id and class selectors, like "#logo" and ".label"
+//   - attribute selectors, like "[fill]", "[fill=red]", "[class~=a]", "[lang|=en]",
+//     "[href^=http]", "[href$=.svg]" and "[d*=L]"
+//   - the descendant (" "), child (">"), next sibling ("+") and subsequent sibling ("~") combinators
+//   - the :first-child, :last-child, :only-child, :nth-child(an+b) and :nth-last-child(an+b) pseudo-classes
+//   - comma separated lists of selectors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type attrSelector struct {
+	name  string
+	op    string // "", "=", "~=", "|=", "^=", "$=" or "*="
+	value string
+}
+
+type nthSelector struct {
+	a, b int
+	last bool // count from the last child instead of the first child
+}
+
+type compoundSelector struct {
+	name    string // tag name, or "" for any tag
+	id      string
+	classes []string
+	attrs   []attrSelector
+	nths    []nthSelector
+}
+
+// complexSelector is a list of compound selectors, separated by combinators.
+// combinators[i] is the combinator between compounds[i] and compounds[i+1].
+type complexSelector struct {
+	compounds   []compoundSelector
+	combinators []byte
+}
+
+type selectorParser struct {
+	s   string
+	pos int
+}
+
+// parseSelector parses a comma separated list of CSS selectors
+func parseSelector(selector string) ([]complexSelector, error) {
+	p := &selectorParser{s: selector}
+	var selectors []complexSelector
+	for {
+		sel, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return selectors, nil
+		}
+		if p.s[p.pos] != ',' {
+			return nil, p.errorf("unexpected %q", p.s[p.pos])
+		}
+		p.pos++
+	}
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid selector %q at position %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) != -1 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func isNameByte(c byte) bool {
+	return c == '-' || c == '_' || c == '|' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+// parseName parses a name, where "|" is used as the namespace separator
+func (p *selectorParser) parseName() (string, error) {
+	start := p.pos
+	for p.pos < len(p.s) && isNameByte(p.s[p.pos]) {
+		if p.s[p.pos] == '|' && p.pos+1 < len(p.s) && p.s[p.pos+1] == '=' {
+			break // the |= attribute operator
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a name")
+	}
+	return strings.Replace(p.s[start:p.pos], "|", ":", -1), nil
+}
+
+func (p *selectorParser) parseComplex() (complexSelector, error) {
+	var sel complexSelector
+	p.skipSpace()
+	for {
+		compound, err := p.parseCompound()
+		if err != nil {
+			return sel, err
+		}
+		sel.compounds = append(sel.compounds, compound)
+		hadSpace := p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] == ',' {
+			return sel, nil
+		}
+		switch c := p.s[p.pos]; c {
+		case '>', '+', '~':
+			p.pos++
+			p.skipSpace()
+			sel.combinators = append(sel.combinators, c)
+		default:
+			if !hadSpace {
+				return sel, p.errorf("unexpected %q", c)
+			}
+			sel.combinators = append(sel.combinators, ' ')
+		}
+	}
+}
+
+func (p *selectorParser) parseCompound() (compoundSelector, error) {
+	var compound compoundSelector
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '*' {
+		p.pos++
+	} else if p.pos < len(p.s) && isNameByte(p.s[p.pos]) {
+		name, err := p.parseName()
+		if err != nil {
+			return compound, err
+		}
+		compound.name = name
+	}
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '#':
+			p.pos++
+			id, err := p.parseName()
+			if err != nil {
+				return compound, err
+			}
+			compound.id = id
+		case '.':
+			p.pos++
+			class, err := p.parseName()
+			if err != nil {
+				return compound, err
+			}
+			compound.classes = append(compound.classes, class)
+		case '[':
+			p.pos++
+			attr, err := p.parseAttr()
+			if err != nil {
+				return compound, err
+			}
+			compound.attrs = append(compound.attrs, attr)
+		case ':':
+			p.pos++
+			nths, err := p.parsePseudo()
+			if err != nil {
+				return compound, err
+			}
+			compound.nths = append(compound.nths, nths...)
+		default:
+			if p.pos == start {
+				return compound, p.errorf("unexpected %q", p.s[p.pos])
+			}
+			return compound, nil
+		}
+	}
+	if p.pos == start {
+		return compound, p.errorf("expected a selector")
+	}
+	return compound, nil
+}
+
+func (p *selectorParser) parseAttr() (attrSelector, error) {
+	var attr attrSelector
+	p.skipSpace()
+	name, err := p.parseName()
+	if err != nil {
+		return attr, err
+	}
+	attr.name = name
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return attr, p.errorf("missing ]")
+	}
+	if p.s[p.pos] == ']' {
+		p.pos++
+		return attr, nil
+	}
+	if p.s[p.pos] == '=' {
+		attr.op = "="
+		p.pos++
+	} else if p.pos+1 < len(p.s) && p.s[p.pos+1] == '=' && strings.IndexByte("~|^$*", p.s[p.pos]) != -1 {
+		attr.op = p.s[p.pos : p.pos+2]
+		p.pos += 2
+	} else {
+		return attr, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) && (p.s[p.pos] == '"' || p.s[p.pos] == '\'') {
+		quote := p.s[p.pos]
+		end := strings.IndexByte(p.s[p.pos+1:], quote)
+		if end == -1 {
+			return attr, p.errorf("missing closing quote")
+		}
+		attr.value = p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	} else {
+		start := p.pos
+		for p.pos < len(p.s) && p.s[p.pos] != ']' && strings.IndexByte(" \t\r\n", p.s[p.pos]) == -1 {
+			p.pos++
+		}
+		attr.value = p.s[start:p.pos]
+	}
+	p.skipSpace()
+	if p.pos >= len(p.s) || p.s[p.pos] != ']' {
+		return attr, p.errorf("missing ]")
+	}
+	p.pos++
+	return attr, nil
+}
+
+// parsePseudo parses a pseudo-class, which may expand to several nth selectors
+func (p *selectorParser) parsePseudo() ([]nthSelector, error) {
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "first-child":
+		return []nthSelector{{0, 1, false}}, nil
+	case "last-child":
+		return []nthSelector{{0, 1, true}}, nil
+	case "only-child":
+		return []nthSelector{{0, 1, false}, {0, 1, true}}, nil
+	case "nth-child", "nth-last-child":
+		if p.pos >= len(p.s) || p.s[p.pos] != '(' {
+			return nil, p.errorf("expected (")
+		}
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end == -1 {
+			return nil, p.errorf("missing )")
+		}
+		a, b, err := parseNth(p.s[p.pos+1 : p.pos+end])
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.pos += end + 1
+		return []nthSelector{{a, b, name == "nth-last-child"}}, nil
+	}
+	return nil, p.errorf("unsupported pseudo-class :%s", name)
+}
+
+// parseNth parses the argument to :nth-child, like "odd", "even", "3", "2n+1" or "-n+3"
+func parseNth(s string) (int, int, error) {
+	s = strings.Replace(strings.ToLower(s), " ", "", -1)
+	switch s {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	invalid := fmt.Errorf("invalid nth expression %q", s)
+	n := strings.IndexByte(s, 'n')
+	if n == -1 {
+		b, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, invalid
+		}
+		return 0, b, nil
+	}
+	var a, b int
+	switch as := s[:n]; as {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		var err error
+		if a, err = strconv.Atoi(as); err != nil {
+			return 0, 0, invalid
+		}
+	}
+	if bs := s[n+1:]; bs != "" {
+		var err error
+		if b, err = strconv.Atoi(strings.TrimPrefix(bs, "+")); err != nil {
+			return 0, 0, invalid
+		}
+	}
+	return a, b, nil
+}
+
+// elementPosition returns the 1-based position of a tag among its element siblings,
+// counting from the first or from the last sibling.
+func (tag *Tag) elementPosition(fromLast bool) int {
+	if tag.parent == nil {
+		return 1
+	}
+	pos, found := 0, false
+	for sib := tag.parent.firstChild; sib != nil; sib = sib.nextSibling {
+		if !sib.isElement() {
+			continue
+		}
+		if sib == tag {
+			found = true
+			if !fromLast {
+				return pos + 1
+			}
+			pos = 0
+		}
+		pos++
+	}
+	if !found {
+		return 1
+	}
+	return pos
+}
+
+func (nth *nthSelector) matches(tag *Tag) bool {
+	pos := tag.elementPosition(nth.last)
+	if nth.a == 0 {
+		return pos == nth.b
+	}
+	n := (pos - nth.b) / nth.a
+	return n >= 0 && n*nth.a+nth.b == pos
+}
+
+func (attr *attrSelector) matches(tag *Tag) bool {
+	if !tag.HasAttrib(attr.name) {
+		return false
+	}
+	value := string(tag.Attrib(attr.name))
+	switch attr.op {
+	case "":
+		return true
+	case "=":
+		return value == attr.value
+	case "~=":
+		for _, word := range strings.Fields(value) {
+			if word == attr.value {
+				return true
+			}
+		}
+		return false
+	case "|=":
+		return value == attr.value || strings.HasPrefix(value, attr.value+"-")
+	case "^=":
+		return attr.value != "" && strings.HasPrefix(value, attr.value)
+	case "$=":
+		return attr.value != "" && strings.HasSuffix(value, attr.value)
+	case "*=":
+		return attr.value != "" && strings.Contains(value, attr.value)
+	}
+	return false
+}
+
+func (compound *compoundSelector) matches(tag *Tag) bool {
+	if !tag.isElement() {
+		return false
+	}
+	if compound.name != "" && string(tag.name) != compound.name {
+		return false
+	}
+	if compound.id != "" && string(tag.Attrib("id")) != compound.id {
+		return false
+	}
+	if len(compound.classes) > 0 {
+		classes := strings.Fields(string(tag.Attrib("class")))
+	NEXT:
+		for _, wanted := range compound.classes {
+			for _, class := range classes {
+				if class == wanted {
+					continue NEXT
+				}
+			}
+			return false
+		}
+	}
+	for i := range compound.attrs {
+		if !compound.attrs[i].matches(tag) {
+			return false
+		}
+	}
+	for i := range compound.nths {
+		if !compound.nths[i].matches(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAt checks if the tag matches the compound selector at index i,
+// and if the tags around it match the compound selectors to the left.
+func (sel *complexSelector) matchesAt(tag *Tag, i int) bool {
+	if !sel.compounds[i].matches(tag) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch sel.combinators[i-1] {
+	case '>':
+		return tag.parent != nil && sel.matchesAt(tag.parent, i-1)
+	case '+':
+		prev := tag.PrevSibling()
+		for prev != nil && !prev.isElement() {
+			prev = prev.PrevSibling()
+		}
+		return prev != nil && sel.matchesAt(prev, i-1)
+	case '~':
+		for prev := tag.PrevSibling(); prev != nil; prev = prev.PrevSibling() {
+			if sel.matchesAt(prev, i-1) {
+				return true
+			}
+		}
+		return false
+	default: // descendant
+		for ancestor := tag.parent; ancestor != nil; ancestor = ancestor.parent {
+			if sel.matchesAt(ancestor, i-1) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func matchesAny(selectors []complexSelector, tag *Tag) bool {
+	for i := range selectors {
+		if selectors[i].matchesAt(tag, len(selectors[i].compounds)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches checks if a tag matches the given CSS selector
+func (tag *Tag) Matches(selector string) (bool, error) {
+	selectors, err := parseSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return matchesAny(selectors, tag), nil
+}
+
+// QuerySelector returns the first descendant of a tag that matches the given CSS selector.
+// Returns nil if no tag matches, and an error if the selector is invalid.
+func (tag *Tag) QuerySelector(selector string) (*Tag, error) {
+	selectors, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	it := tag.Descendants()
+	for t := it.Next(); t != nil; t = it.Next() {
+		if matchesAny(selectors, t) {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// QuerySelectorAll returns all descendants of a tag that matches the given CSS selector,
+// in document order. Returns an error if the selector is invalid.
+func (tag *Tag) QuerySelectorAll(selector string) ([]*Tag, error) {
+	selectors, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	var found []*Tag
+	it := tag.Descendants()
+	for t := it.Next(); t != nil; t = it.Next() {
+		if matchesAny(selectors, t) {
+			found = append(found, t)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,67 @@
+package tinysvg
+
+import (
+	"testing"
+)
+
+func TestQuerySelector(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	g := svg.AddNewTag([]byte("g"))
+	g.AddAttrib("id", []byte("chart"))
+	for i := 0; i < 5; i++ {
+		rect := g.AddRect(i*10, 0, 10, 10)
+		rect.AddAttrib("class", []byte("bar"))
+		if i%2 == 0 {
+			rect.Fill("red")
+			rect.AddAttrib("class", []byte("bar even"))
+		}
+	}
+	svg.AddNewTag([]byte("glyph"))
+	svg.Box(0, 0, 10, 10, "red")
+
+	for selector, expected := range map[string]int{
+		"g":                        1,
+		"rect":                     6,
+		"#chart > rect":            5,
+		"svg > rect":               1,
+		"svg rect":                 6,
+		".bar.even":                3,
+		"rect[fill=red]":           4,
+		"rect[fill='red'].bar":     3,
+		"#chart rect:nth-child(2)": 1,
+		"rect:nth-child(odd)":      4,
+		"rect:nth-child(-n+2)":     2,
+		"rect:last-child":          2,
+		"g + glyph":                1,
+		"g ~ rect":                 1,
+		"g, glyph":                 2,
+		"[class~=even]":            3,
+		"*":                        9,
+	} {
+		tags, err := document.QuerySelectorAll(selector)
+		if err != nil {
+			t.Fatalf("%s: %v\n", selector, err)
+		}
+		if len(tags) != expected {
+			t.Fatalf("%s: expected %d tags, got %d\n", selector, expected, len(tags))
+		}
+	}
+
+	first, err := document.QuerySelector("#chart > .bar:nth-child(2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != g.Child(1) {
+		t.Fatal("wrong tag")
+	}
+	none, err := document.QuerySelector("circle")
+	if err != nil || none != nil {
+		t.Fatalf("expected no tag and no error, got %v and %v\n", none, err)
+	}
+
+	for _, selector := range []string{"", "rect[", "rect:hover", "a >", ",", "rect:nth-child(x)"} {
+		if _, err := document.QuerySelectorAll(selector); err == nil {
+			t.Fatalf("expected an error for %q\n", selector)
+		}
+	}
+}
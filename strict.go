@@ -0,0 +1,117 @@
+package tinysvg
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StrictError is recorded when an element or attribute that is not allowed
+// in SVG Tiny 1.2 is added to an image in strict mode.
+// File and Line is the location of the code that added it.
+type StrictError struct {
+	Path    string
+	Message string
+	File    string
+	Line    int
+}
+
+// packageDir is the directory of the source files of this package,
+// used for finding the first caller outside of this package.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// Error returns the location, path and message of the error
+func (e *StrictError) Error() string {
+	return e.File + ":" + strconv.Itoa(e.Line) + ": " + e.Path + ": " + e.Message
+}
+
+// SetStrict enables or disables strict mode. In strict mode, elements and
+// attributes that are not allowed in SVG Tiny 1.2 under their current parent
+// are recorded as errors when they are added, together with the location of
+// the code that added them. The errors can be retrieved with Errors.
+func (image *Document) SetStrict(strict bool) {
+	image.strict = strict
+}
+
+// Strict checks if strict mode is enabled
+func (image *Document) Strict() bool {
+	return image.strict
+}
+
+// Errors returns the errors that have been recorded in strict mode
+func (image *Document) Errors() []error {
+	return image.strictErrors
+}
+
+// strictDocument returns the document of a tag, if it is in strict mode
+func (tag *Tag) strictDocument() *Document {
+	if image := tag.Document(); image != nil && image.strict {
+		return image
+	}
+	return nil
+}
+
+// record records a strict mode error for the given tag, with the location of the first caller outside of this package
+func (image *Document) record(tag *Tag, message string) {
+	e := &StrictError{Path: tag.path(), Message: message}
+	pc := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go") {
+			e.File, e.Line = frame.File, frame.Line
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	image.strictErrors = append(image.strictErrors, e)
+}
+
+// checkStrictChild checks a child that has just been added to a tag, and all its descendants,
+// if the tag belongs to a document in strict mode
+func (tag *Tag) checkStrictChild(child *Tag) {
+	image := tag.strictDocument()
+	if image == nil || !child.isElement() {
+		return
+	}
+	child.Walk(func(t *Tag, depth int) WalkAction {
+		if !t.isElement() {
+			return WalkSkipChildren
+		}
+		name := string(t.name)
+		if t.parent.isElement() {
+			if message := checkChild(string(t.parent.name), name); message != "" {
+				image.record(t, message)
+				return WalkSkipChildren
+			}
+		}
+		for _, attr := range t.Attribs() {
+			if message := checkAttrib(name, attr.Name, attr.Value); message != "" {
+				image.record(t, message)
+			}
+		}
+		if spec, ok := tinyElements[name]; !ok || spec.foreign {
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+}
+
+// checkStrictAttrib checks an attribute that has just been added to a tag,
+// if the tag belongs to a document in strict mode
+func (tag *Tag) checkStrictAttrib(attrName string, attrValue []byte) {
+	if !tag.isElement() {
+		return
+	}
+	if image := tag.strictDocument(); image != nil {
+		if message := checkAttrib(string(tag.name), attrName, attrValue); message != "" {
+			image.record(tag, message)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package tinysvg
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The stroke font is drawn on a grid where capital letters go from 0 (top) to 8 (baseline),
+	// lowercase letters from 3 to 8 and descenders down to 11. The font size is 12 units.
+	strokeFontEm       = 12.0
+	strokeFontBaseline = 8.0
+	strokeFontSpacing  = 2.0 // the space after each glyph
+)
+
+// strokeGlyphSource has the strokes of each glyph in the built-in single-stroke font.
+// Strokes are separated by "|", and each stroke is a list of "x,y" points.
+var strokeGlyphSource = map[rune]string{
+	' ':  "",
+	'!':  "0,0 0,5.5|0,7.5 0,8",
+	'"':  "0,0 0,2|1.5,0 1.5,2",
+	'#':  "1.5,0 0.5,8|3.5,0 2.5,8|0,2.5 4,2.5|0,5.5 4,5.5",
+	'$':  "4,1 3,0 1,0 0,1 0,3 1,4 3,4 4,5 4,7 3,8 1,8 0,7|2,-1 2,9",
+	'%':  "4,0 0,8|0,0 1,0 1,1 0,1 0,0|3,7 4,7 4,8 3,8 3,7",
+	'&':  "4,8 1,3 1,1 2,0 3,1 3,2 0,5 0,7 1,8 2,8 4,5",
+	'\'': "0,0 0,2",
+	'(':  "2,-0.5 0.5,2 0.5,6 2,8.5",
+	')':  "0,-0.5 1.5,2 1.5,6 0,8.5",
+	'*':  "2,1 2,5|0.5,2 3.5,4|3.5,2 0.5,4",
+	'+':  "2,2 2,6|0,4 4,4",
+	',':  "0.5,7.5 0.5,8 0,9",
+	'-':  "0,4 3,4",
+	'.':  "0,7.5 0,8",
+	'/':  "0,8 4,0",
+	'0':  "1,0 3,0 4,1 4,7 3,8 1,8 0,7 0,1 1,0|4,1 0,7",
+	'1':  "1,1 2,0 2,8|1,8 3,8",
+	'2':  "0,1 1,0 3,0 4,1 4,3 0,8 4,8",
+	'3':  "0,1 1,0 3,0 4,1 4,3 3,4 1,4|3,4 4,5 4,7 3,8 1,8 0,7",
+	'4':  "3,8 3,0 0,6 4,6",
+	'5':  "4,0 0,0 0,4 3,4 4,5 4,7 3,8 1,8 0,7",
+	'6':  "4,1 3,0 1,0 0,1 0,7 1,8 3,8 4,7 4,5 3,4 0,4",
+	'7':  "0,0 4,0 1,8",
+	'8':  "1,0 3,0 4,1 4,3 3,4 1,4 0,3 0,1 1,0|1,4 0,5 0,7 1,8 3,8 4,7 4,5 3,4",
+	'9':  "4,4 1,4 0,3 0,1 1,0 3,0 4,1 4,7 3,8 1,8 0,7",
+	':':  "0,3.5 0,4|0,7.5 0,8",
+	';':  "0.5,3.5 0.5,4|0.5,7.5 0.5,8 0,9",
+	'<':  "4,1 0,4 4,7",
+	'=':  "0,3 4,3|0,5 4,5",
+	'>':  "0,1 4,4 0,7",
+	'?':  "0,1 1,0 3,0 4,1 4,2 2,4 2,5.5|2,7.5 2,8",
+	'@':  "3,3 2,3 1.5,4 2,5 3,5 3,3|3,5 4,5 4,1 3,0 1,0 0,1 0,7 1,8 4,8",
+	'A':  "0,8 2,0 4,8|0.75,5 3.25,5",
+	'B':  "0,8 0,0 3,0 4,1 4,3 3,4 0,4|3,4 4,5 4,7 3,8 0,8",
+	'C':  "4,1 3,0 1,0 0,1 0,7 1,8 3,8 4,7",
+	'D':  "0,0 0,8 2,8 4,6 4,2 2,0 0,0",
+	'E':  "4,0 0,0 0,8 4,8|0,4 3,4",
+	'F':  "4,0 0,0 0,8|0,4 3,4",
+	'G':  "4,1 3,0 1,0 0,1 0,7 1,8 3,8 4,7 4,4 2,4",
+	'H':  "0,0 0,8|4,0 4,8|0,4 4,4",
+	'I':  "0,0 2,0|1,0 1,8|0,8 2,8",
+	'J':  "4,0 4,7 3,8 1,8 0,7",
+	'K':  "0,0 0,8|4,0 0,5|1.5,3.5 4,8",
+	'L':  "0,0 0,8 4,8",
+	'M':  "0,8 0,0 2.5,5 5,0 5,8",
+	'N':  "0,8 0,0 4,8 4,0",
+	'O':  "1,0 3,0 4,1 4,7 3,8 1,8 0,7 0,1 1,0",
+	'P':  "0,8 0,0 3,0 4,1 4,3 3,4 0,4",
+	'Q':  "1,0 3,0 4,1 4,7 3,8 1,8 0,7 0,1 1,0|2,6 4,8",
+	'R':  "0,8 0,0 3,0 4,1 4,3 3,4 0,4|2,4 4,8",
+	'S':  "4,1 3,0 1,0 0,1 0,3 1,4 3,4 4,5 4,7 3,8 1,8 0,7",
+	'T':  "0,0 4,0|2,0 2,8",
+	'U':  "0,0 0,7 1,8 3,8 4,7 4,0",
+	'V':  "0,0 2,8 4,0",
+	'W':  "0,0 1.25,8 2.5,3 3.75,8 5,0",
+	'X':  "0,0 4,8|4,0 0,8",
+	'Y':  "0,0 2,4 4,0|2,4 2,8",
+	'Z':  "0,0 4,0 0,8 4,8",
+	'[':  "2,-0.5 0,-0.5 0,8.5 2,8.5",
+	'\\': "0,0 4,8",
+	']':  "0,-0.5 2,-0.5 2,8.5 0,8.5",
+	'^':  "0,3 2,0 4,3",
+	'_':  "0,9 4,9",
+	'`':  "0,0 1,1",
+	'a':  "1,3 3,3 4,4 4,8|4,5 1,5 0,6 0,7 1,8 3,8 4,7",
+	'b':  "0,0 0,8|0,4 1,3 3,3 4,4 4,7 3,8 1,8 0,7",
+	'c':  "4,4 3,3 1,3 0,4 0,7 1,8 3,8 4,7",
+	'd':  "4,0 4,8|4,4 3,3 1,3 0,4 0,7 1,8 3,8 4,7",
+	'e':  "0,5.5 4,5.5 4,4 3,3 1,3 0,4 0,7 1,8 3,8 4,7",
+	'f':  "3,0 2,0 1,1 1,8|0,3 3,3",
+	'g':  "4,3 4,10 3,11 1,11 0,10|4,4 3,3 1,3 0,4 0,6 1,7 3,7 4,6",
+	'h':  "0,0 0,8|0,4 1,3 3,3 4,4 4,8",
+	'i':  "0,3 0,8|0,1 0,1.5",
+	'j':  "2,3 2,10 1,11 0,11|2,1 2,1.5",
+	'k':  "0,0 0,8|3,3 0,6|1,5 3,8",
+	'l':  "0,0 0,7 1,8",
+	'm':  "0,3 0,8|0,4 1,3 2,3 3,4 3,8|3,4 4,3 5,3 6,4 6,8",
+	'n':  "0,3 0,8|0,4 1,3 3,3 4,4 4,8",
+	'o':  "1,3 3,3 4,4 4,7 3,8 1,8 0,7 0,4 1,3",
+	'p':  "0,3 0,11|0,4 1,3 3,3 4,4 4,7 3,8 1,8 0,7",
+	'q':  "4,3 4,11|4,4 3,3 1,3 0,4 0,7 1,8 3,8 4,7",
+	'r':  "0,3 0,8|0,5 2,3 3,3",
+	's':  "4,4 3,3 1,3 0,4 1,5.5 3,5.5 4,6.5 3,8 1,8 0,7",
+	't':  "1,1 1,7 2,8 3,8|0,3 3,3",
+	'u':  "0,3 0,7 1,8 3,8 4,7|4,3 4,8",
+	'v':  "0,3 2,8 4,3",
+	'w':  "0,3 1.5,8 3,4 4.5,8 6,3",
+	'x':  "0,3 4,8|4,3 0,8",
+	'y':  "0,3 2,8|4,3 1,11",
+	'z':  "0,3 4,3 0,8 4,8",
+	'{':  "2,-0.5 1,0 1,3 0,4 1,5 1,8 2,8.5",
+	'|':  "0,-0.5 0,8.5",
+	'}':  "0,-0.5 1,0 1,3 2,4 1,5 1,8 0,8.5",
+	'~':  "0,4.5 1,3.5 3,4.5 4,3.5",
+}
+
+// strokeGlyph is a glyph in the built-in single-stroke font
+type strokeGlyph struct {
+	strokes [][]Vec2
+	advance float64
+}
+
+var (
+	strokeGlyphs = parseStrokeGlyphs(strokeGlyphSource)
+
+	// missingStrokeGlyph is drawn for characters that are not in the font
+	missingStrokeGlyph = parseStrokeGlyph("0,0 4,0 4,8 0,8 0,0")
+)
+
+// parseStrokeGlyph parses the strokes of a glyph. The advance is the width of the glyph, plus spacing.
+func parseStrokeGlyph(source string) *strokeGlyph {
+	g := &strokeGlyph{advance: 2 * strokeFontSpacing} // the width of a space
+	if source == "" {
+		return g
+	}
+	width := 0.0
+	for _, stroke := range strings.Split(source, "|") {
+		var points []Vec2
+		for _, point := range strings.Fields(stroke) {
+			xy := strings.Split(point, ",")
+			x, _ := strconv.ParseFloat(xy[0], 64)
+			y, _ := strconv.ParseFloat(xy[1], 64)
+			points = append(points, Vec2{x, y})
+			width = math.Max(width, x)
+		}
+		g.strokes = append(g.strokes, points)
+	}
+	g.advance = width + strokeFontSpacing
+	return g
+}
+
+func parseStrokeGlyphs(source map[rune]string) map[rune]*strokeGlyph {
+	glyphs := make(map[rune]*strokeGlyph, len(source))
+	for r, s := range source {
+		glyphs[r] = parseStrokeGlyph(s)
+	}
+	return glyphs
+}
+
+// strokeNumber formats a coordinate with at most two decimals
+func strokeNumber(x float64) string {
+	return strconv.FormatFloat(math.Round(x*100)/100+0, 'f', -1, 64)
+}
+
+// StrokeTextWidth returns the width of the widest line of the given text, when drawn with TextAsPath
+func StrokeTextWidth(size float64, message string) float64 {
+	scale := size / strokeFontEm
+	widest := 0.0
+	for _, line := range strings.Split(message, "\n") {
+		width := 0.0
+		for _, r := range line {
+			g, ok := strokeGlyphs[r]
+			if !ok {
+				g = missingStrokeGlyph
+			}
+			width += g.advance
+		}
+		if len(line) > 0 {
+			width -= strokeFontSpacing // no spacing after the last glyph
+		}
+		widest = math.Max(widest, width*scale)
+	}
+	return widest
+}
+
+// TextAsPath adds the given text as a path element, drawn with a built-in single-stroke vector font.
+// The text is rendered the same way everywhere, without depending on the fonts of the viewer.
+// The position is the start of the baseline, like for text elements, and size is the font size.
+// Lines are separated by newlines. Characters that are not in the font are drawn as boxes.
+// If the color is nil, the stroke is "currentColor". If there is nothing to draw, like for an
+// empty message, no path is added and nil is returned.
+func (svg *Tag) TextAsPath(p *Pos, size float64, message string, c *Color) *Tag {
+	scale := size / strokeFontEm
+	var buf bytes.Buffer
+	for n, line := range strings.Split(message, "\n") {
+		x := p.X
+		y := p.Y + float64(n)*1.2*size - strokeFontBaseline*scale
+		for _, r := range line {
+			g, ok := strokeGlyphs[r]
+			if !ok {
+				g = missingStrokeGlyph
+			}
+			for _, stroke := range g.strokes {
+				for i, point := range stroke {
+					if buf.Len() > 0 {
+						buf.WriteByte(' ')
+					}
+					if i == 0 {
+						buf.WriteString("M")
+					} else if i == 1 {
+						buf.WriteString("L")
+					}
+					buf.WriteString(strokeNumber(x + point.X*scale))
+					buf.WriteByte(' ')
+					buf.WriteString(strokeNumber(y + point.Y*scale))
+				}
+			}
+			x += g.advance * scale
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	path := svg.AddNewTag([]byte("path"))
+	path.AddAttribMap(map[string][]byte{
+		"d":               buf.Bytes(),
+		"fill":            []byte("none"),
+		"stroke-width":    []byte(strokeNumber(scale)),
+		"stroke-linecap":  []byte("round"),
+		"stroke-linejoin": []byte("round"),
+	})
+	if c == nil {
+		path.AddAttrib("stroke", []byte("currentColor"))
+	} else {
+		path.Stroke2(c)
+	}
+	return path
+}
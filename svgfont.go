@@ -0,0 +1,155 @@
+package tinysvg
+
+import (
+	"sort"
+)
+
+// SVGFont is a font that can be embedded in an image as a font element,
+// and then used by text elements by referring to its family name.
+type SVGFont struct {
+	Family     string
+	UnitsPerEm float64
+	Ascent     float64
+	Descent    float64 // a positive number, the distance below the baseline
+	HorizAdvX  float64 // the default advance of the glyphs
+	Glyphs     []*Glyph
+	Missing    *Glyph // drawn for characters that have no glyph, may be nil
+}
+
+// Glyph is a glyph in an SVG font. The path data is in font units, where the y axis points up
+// and the baseline is at 0. A HorizAdvX of 0 means that the default advance of the font is used.
+type Glyph struct {
+	Unicode   string // one or more characters, more than one is a ligature
+	Name      string // optional
+	D         string // path data
+	HorizAdvX float64
+}
+
+// NewSVGFont creates a new SVG font without glyphs
+func NewSVGFont(family string, unitsPerEm, ascent, descent, horizAdvX float64) *SVGFont {
+	return &SVGFont{Family: family, UnitsPerEm: unitsPerEm, Ascent: ascent, Descent: descent, HorizAdvX: horizAdvX}
+}
+
+// AddGlyph adds a glyph for the given character or characters, from path data
+func (f *SVGFont) AddGlyph(unicode, d string, horizAdvX float64) *Glyph {
+	g := &Glyph{Unicode: unicode, D: d, HorizAdvX: horizAdvX}
+	f.Glyphs = append(f.Glyphs, g)
+	return g
+}
+
+// SetMissingGlyph sets the glyph that is drawn for characters that have no glyph
+func (f *SVGFont) SetMissingGlyph(d string, horizAdvX float64) *Glyph {
+	f.Missing = &Glyph{D: d, HorizAdvX: horizAdvX}
+	return f.Missing
+}
+
+// Font returns a Font that refers to this SVG font by family name, for use with the text helpers
+func (f *SVGFont) Font(size int) *Font {
+	return &Font{Family: f.Family, Size: size}
+}
+
+// Subset returns a copy of the font with only the glyphs that are needed for the given text.
+// Ligatures are kept if all their characters are used. The missing glyph is always kept.
+func (f *SVGFont) Subset(text string) *SVGFont {
+	used := make(map[rune]bool)
+	for _, r := range text {
+		used[r] = true
+	}
+	subset := *f
+	subset.Glyphs = nil
+	for _, g := range f.Glyphs {
+		keep := g.Unicode != ""
+		for _, r := range g.Unicode {
+			if !used[r] {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			subset.Glyphs = append(subset.Glyphs, g)
+		}
+	}
+	return &subset
+}
+
+// AddSVGFont adds a font element with a font-face element, the missing glyph and the glyphs of the given font.
+// The font element is given an id.
+func (svg *Tag) AddSVGFont(f *SVGFont) *Tag {
+	font := NewTag([]byte("font"))
+	if f.HorizAdvX > 0 {
+		font.AddAttrib("horiz-adv-x", f2b(f.HorizAdvX))
+	}
+	fontFace := font.AddNewTag([]byte("font-face"))
+	fontFace.AddAttrib("font-family", escapeXML([]byte(f.Family), true))
+	for _, attr := range []struct {
+		name  string
+		value float64
+	}{{"units-per-em", f.UnitsPerEm}, {"ascent", f.Ascent}, {"descent", f.Descent}} {
+		if attr.value > 0 {
+			fontFace.AddAttrib(attr.name, f2b(attr.value))
+		}
+	}
+	if f.Missing != nil {
+		font.addGlyph("missing-glyph", f.Missing)
+	}
+	for _, g := range f.Glyphs {
+		font.addGlyph("glyph", g)
+	}
+	svg.AddTag(font)
+	font.EnsureID()
+	return font
+}
+
+// addGlyph adds a glyph or missing-glyph element
+func (svg *Tag) addGlyph(name string, g *Glyph) {
+	glyph := svg.AddNewTag([]byte(name))
+	if g.Unicode != "" {
+		glyph.AddAttrib("unicode", escapeXML([]byte(g.Unicode), true))
+	}
+	if g.Name != "" {
+		glyph.AddAttrib("glyph-name", escapeXML([]byte(g.Name), true))
+	}
+	if g.HorizAdvX > 0 {
+		glyph.AddAttrib("horiz-adv-x", f2b(g.HorizAdvX))
+	}
+	if g.D != "" {
+		glyph.AddAttrib("d", []byte(g.D))
+	}
+}
+
+// TextCharacters returns the characters that are used in the text, tspan and textArea elements of the image,
+// sorted and without duplicates
+func (image *Document) TextCharacters() string {
+	used := make(map[rune]bool)
+	image.Walk(func(t *Tag, depth int) WalkAction {
+		if !t.isElement() {
+			return WalkSkipChildren
+		}
+		switch string(t.name) {
+		case "text", "tspan", "textArea":
+			for _, r := range t.textContent() {
+				used[r] = true
+			}
+		}
+		return WalkContinue
+	})
+	var runes []rune
+	for r := range used {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return string(runes)
+}
+
+// EmbedFont adds the given font to the image as the first child of the svg element, subset to
+// the characters that are used in the text elements of the image. The font should be embedded after
+// all text has been added. Returns the font element, or nil if the image has no svg element.
+func (image *Document) EmbedFont(f *SVGFont) *Tag {
+	svg := image.topElement()
+	if svg == nil || string(svg.name) != svgTag {
+		return nil
+	}
+	font := svg.AddSVGFont(f.Subset(image.TextCharacters()))
+	svg.InsertChild(0, font)
+	return font
+}
@@ -6,19 +6,31 @@ package tinysvg
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 )
 
-// Tag represents an XML tag, as part of a larger XML document
+// Attribute is a name and value pair, as returned by Tag.Attribs
+type Attribute struct {
+	Name  string
+	Value []byte // nil for attributes without a value
+}
+
+// Tag represents an XML tag, as part of a larger XML document.
+// A tag can also be another kind of node, like a comment or a CDATA section. See NodeKind.
 type Tag struct {
+	kind        NodeKind
 	name        []byte
 	content     []byte
 	lastContent []byte
 	xmlContent  []byte
 	attrs       map[string][]byte
-	nextSibling *Tag // siblings
-	firstChild  *Tag // first child
+	nextSibling *Tag      // siblings
+	firstChild  *Tag      // first child
+	parent      *Tag      // parent, or nil
+	owner       *Document // the document, only set for the root tag of a document
 }
 
 var (
@@ -29,6 +41,10 @@ var (
 	equalEscapedQuote = []byte("=\"")
 	escapedQuoteSpace = []byte("\" ")
 	space             = []byte{' '}
+
+	ErrNotAChild = errors.New("the given tag is not a child of this tag")
+	ErrNoParent  = errors.New("the tag has no parent")
+	ErrHierarchy = errors.New("a tag can not be added to itself or to one of its descendants")
 )
 
 // NewTag creates a new tag based on the given name.
@@ -56,6 +72,7 @@ func (tag *Tag) AddTag(child *Tag) {
 // AddAttrib adds an attribute to a tag, for instance "size" and "20"
 func (tag *Tag) AddAttrib(attrName string, attrValue []byte) {
 	tag.attrs[attrName] = attrValue
+	tag.checkStrictAttrib(attrName, attrValue)
 }
 
 // AddAttribMap adds attributes based on a given map
@@ -63,12 +80,59 @@ func (tag *Tag) AddAttribMap(attrMap map[string][]byte) {
 	//attrName string, attrValue []byte) {
 	for attrName, attrValue := range attrMap {
 		tag.attrs[attrName] = attrValue
+		tag.checkStrictAttrib(attrName, attrValue)
 	}
 }
 
 // AddSingularAttrib adds attribute without a value
 func (tag *Tag) AddSingularAttrib(attrName string) {
 	tag.attrs[attrName] = nil
+	tag.checkStrictAttrib(attrName, nil)
+}
+
+// Attrib returns the value of the given attribute, or nil if it is not set.
+// Use HasAttrib to check for attributes without a value.
+func (tag *Tag) Attrib(attrName string) []byte {
+	return tag.attrs[attrName]
+}
+
+// HasAttrib checks if the given attribute is set
+func (tag *Tag) HasAttrib(attrName string) bool {
+	_, ok := tag.attrs[attrName]
+	return ok
+}
+
+// RemoveAttrib removes the given attribute, if it is set
+func (tag *Tag) RemoveAttrib(attrName string) {
+	delete(tag.attrs, attrName)
+}
+
+// Attribs returns all attributes of a tag, sorted by name
+func (tag *Tag) Attribs() []Attribute {
+	attribs := make([]Attribute, 0, len(tag.attrs))
+	for name, value := range tag.attrs {
+		attribs = append(attribs, Attribute{name, value})
+	}
+	sort.Slice(attribs, func(i, j int) bool {
+		return attribs[i].Name < attribs[j].Name
+	})
+	return attribs
+}
+
+// Name returns the name of a tag
+func (tag *Tag) Name() []byte {
+	return tag.name
+}
+
+// Content returns the content of a tag, as added with AddContent
+func (tag *Tag) Content() []byte {
+	return tag.content
+}
+
+// LastContent returns the content that is rendered after the children of a tag,
+// as added with AppendContent
+func (tag *Tag) LastContent() []byte {
+	return tag.lastContent
 }
 
 // GetAttrString returns a []byte that represents all the attribute keys and
@@ -92,11 +156,26 @@ func (tag *Tag) GetAttrString() []byte {
 	return ret
 }
 
+// isRoot checks if a tag is the root tag of a document. The name of a root tag
+// is either empty or holds preceding declarations, like <?xml version="1.0"?>,
+// and only the content and children of the root tag are rendered after it.
+func (tag *Tag) isRoot() bool {
+	return tag.kind == ElementNode && (len(tag.name) == 0 || tag.name[0] == '<')
+}
+
+// isElement checks if a tag is a regular element, and not the root tag of a document or another kind of node
+func (tag *Tag) isElement() bool {
+	return tag.kind == ElementNode && !tag.isRoot()
+}
+
 // getFlatXML renders XML.
 // This will generate a []byte for a tag, non-recursively.
 func (tag *Tag) getFlatXML() []byte {
+	if tag.kind != ElementNode {
+		return tag.nodeBytes()
+	}
 	// For the root tag
-	if (len(tag.name) > 0) && (tag.name[0] == '<') {
+	if tag.isRoot() {
 		ret := make([]byte, 0, len(tag.name)+len(tag.content)+len(tag.xmlContent)+len(tag.lastContent))
 		ret = append(ret, tag.name...)
 		ret = append(ret, tag.content...)
@@ -151,9 +230,13 @@ func (tag *Tag) getFlatXML() []byte {
 // writeFlatXML renders an XML tag to an io.Writer.
 // This will generate a bytes for a tag, non-recursively.
 func (tag *Tag) writeFlatXML(w io.Writer) (n int64, err error) {
+	if tag.kind != ElementNode {
+		x, err := w.Write(tag.nodeBytes())
+		return int64(x), err
+	}
 	nameLen := len(tag.name)
 
-	if nameLen > 0 && tag.name[0] == '<' { // root tag
+	if tag.isRoot() {
 		parts := [][]byte{tag.name, tag.content, tag.xmlContent, tag.lastContent}
 		for _, part := range parts {
 			if len(part) > 0 {
@@ -236,15 +319,196 @@ func (tag *Tag) GetChildren() []*Tag {
 	return children
 }
 
-// AddChild adds a tag as a child to another tag
-func (tag *Tag) AddChild(child *Tag) {
+// AddChild adds a tag as a child to another tag.
+// If the child already has a parent, it is moved.
+// Returns ErrHierarchy if the child is this tag or one of its ancestors.
+func (tag *Tag) AddChild(child *Tag) error {
+	if tag.hasAncestor(child) {
+		return ErrHierarchy
+	}
+	child.Detach()
+	child.parent = tag
+	child.nextSibling = nil
 	if tag.firstChild == nil {
 		tag.firstChild = child
-		return
+	} else {
+		tag.LastChild().nextSibling = child
+	}
+	tag.checkStrictChild(child)
+	return nil
+}
+
+// hasAncestor checks if the given tag is this tag or one of its ancestors
+func (tag *Tag) hasAncestor(ancestor *Tag) bool {
+	for t := tag; t != nil; t = t.parent {
+		if t == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Parent returns the parent of a tag, or nil if the tag has no parent
+func (tag *Tag) Parent() *Tag {
+	return tag.parent
+}
+
+// FirstChild returns the first child of a tag, or nil if there are no children
+func (tag *Tag) FirstChild() *Tag {
+	return tag.firstChild
+}
+
+// NextSibling returns the next sibling of a tag, or nil if this is the last one
+func (tag *Tag) NextSibling() *Tag {
+	return tag.nextSibling
+}
+
+// PrevSibling returns the previous sibling of a tag, or nil if this is the first one
+func (tag *Tag) PrevSibling() *Tag {
+	if tag.parent == nil {
+		return nil
+	}
+	var prev *Tag
+	for child := tag.parent.firstChild; child != nil; child = child.nextSibling {
+		if child == tag {
+			return prev
+		}
+		prev = child
+	}
+	return nil
+}
+
+// Child returns the child at the given index, or nil if the index is out of range
+func (tag *Tag) Child(index int) *Tag {
+	if index < 0 {
+		return nil
+	}
+	child := tag.firstChild
+	for i := 0; child != nil; i++ {
+		if i == index {
+			return child
+		}
+		child = child.nextSibling
+	}
+	return nil
+}
+
+// Index returns the position of a tag among the children of its parent,
+// or -1 if the tag has no parent.
+func (tag *Tag) Index() int {
+	if tag.parent == nil {
+		return -1
 	}
-	lastChild := tag.LastChild()
+	i := 0
+	for child := tag.parent.firstChild; child != nil; child = child.nextSibling {
+		if child == tag {
+			return i
+		}
+		i++
+	}
+	return -1
+}
+
+// RemoveChild removes the given child from a tag.
+// Returns ErrNotAChild if the given tag is not a child of this tag.
+func (tag *Tag) RemoveChild(child *Tag) error {
+	if child == nil || child.parent != tag {
+		return ErrNotAChild
+	}
+	if tag.firstChild == child {
+		tag.firstChild = child.nextSibling
+	} else {
+		prev := child.PrevSibling()
+		if prev == nil {
+			return ErrNotAChild
+		}
+		prev.nextSibling = child.nextSibling
+	}
+	child.parent = nil
 	child.nextSibling = nil
-	lastChild.nextSibling = child
+	return nil
+}
+
+// Detach removes a tag from its parent, if it has one
+func (tag *Tag) Detach() {
+	if tag.parent != nil {
+		tag.parent.RemoveChild(tag)
+	}
+}
+
+// InsertBefore inserts newChild right before refChild, which must be a child of this tag.
+// If refChild is nil, newChild is added as the last child.
+// Returns ErrHierarchy if newChild is this tag or one of its ancestors.
+func (tag *Tag) InsertBefore(newChild, refChild *Tag) error {
+	if refChild == nil {
+		return tag.AddChild(newChild)
+	}
+	if refChild.parent != tag {
+		return ErrNotAChild
+	}
+	if newChild == refChild {
+		return nil
+	}
+	if tag.hasAncestor(newChild) {
+		return ErrHierarchy
+	}
+	newChild.Detach()
+	prev := refChild.PrevSibling()
+	newChild.parent = tag
+	newChild.nextSibling = refChild
+	if prev == nil {
+		tag.firstChild = newChild
+	} else {
+		prev.nextSibling = newChild
+	}
+	tag.checkStrictChild(newChild)
+	return nil
+}
+
+// InsertAfter inserts newChild right after refChild, which must be a child of this tag.
+// If refChild is nil, newChild is added as the first child.
+// Returns ErrHierarchy if newChild is this tag or one of its ancestors.
+func (tag *Tag) InsertAfter(newChild, refChild *Tag) error {
+	if refChild == nil {
+		return tag.InsertBefore(newChild, tag.firstChild)
+	}
+	if refChild.parent != tag {
+		return ErrNotAChild
+	}
+	if newChild == refChild {
+		return nil
+	}
+	if tag.hasAncestor(newChild) {
+		return ErrHierarchy
+	}
+	newChild.Detach()
+	newChild.parent = tag
+	newChild.nextSibling = refChild.nextSibling
+	refChild.nextSibling = newChild
+	tag.checkStrictChild(newChild)
+	return nil
+}
+
+// InsertChild inserts a child at the given index.
+// If the index is out of range, the child is added as the last child.
+func (tag *Tag) InsertChild(index int, child *Tag) error {
+	return tag.InsertBefore(child, tag.Child(index))
+}
+
+// ReplaceWith replaces a tag with another tag, in the same position under the same parent.
+// Returns ErrNoParent if the tag has no parent.
+func (tag *Tag) ReplaceWith(other *Tag) error {
+	parent := tag.parent
+	if parent == nil {
+		return ErrNoParent
+	}
+	if other == tag {
+		return nil
+	}
+	if err := parent.InsertBefore(other, tag); err != nil {
+		return err
+	}
+	return parent.RemoveChild(tag)
 }
 
 // AddContent adds text to a tag.
@@ -302,9 +566,12 @@ func (tag *Tag) CountSiblings() int {
 	return count
 }
 
-// LastChild returns the last child of a tag
+// LastChild returns the last child of a tag, or nil if there are no children
 func (tag *Tag) LastChild() *Tag {
 	child := tag.firstChild
+	if child == nil {
+		return nil
+	}
 	for child.nextSibling != nil {
 		child = child.nextSibling
 	}
@@ -313,31 +580,30 @@ func (tag *Tag) LastChild() *Tag {
 
 // GetTag finds a tag by name and returns an error if not found.
 // Returns the first tag that matches.
+// If the name has a prefix that is declared or registered, like "dc:title", tags with the same
+// namespace and local name are matched, regardless of the prefix they use.
 func (tag *Tag) GetTag(name []byte) (*Tag, error) {
-	if bytes.Index(tag.name, name) == 0 {
-		return tag, nil
+	if prefix := namePrefix(string(name)); prefix != "" && prefix != "xmlns" {
+		if uri := tag.LookupNamespace(prefix); uri != "" {
+			return tag.GetTagNS(uri, string(name[len(prefix)+1:]))
+		}
 	}
-	couldNotFindError := fmt.Errorf("could not find tag: %s", name)
-	if tag.CountChildren() == 0 {
-		// No children. Not found so far.
-		return nil, couldNotFindError
+	if tag.kind == ElementNode && bytes.Index(tag.name, name) == 0 {
+		return tag, nil
 	}
-
-	child := tag.firstChild
-	for child != nil {
-		found, err := child.GetTag(name)
-		if err == nil {
-			return found, err
+	it := tag.Descendants()
+	for t := it.Next(); t != nil; t = it.Next() {
+		if t.kind == ElementNode && bytes.Index(t.name, name) == 0 {
+			return t, nil
 		}
-		child = child.nextSibling
 	}
-
-	return nil, couldNotFindError
+	return nil, fmt.Errorf("could not find tag: %s", name)
 }
 
 // ShallowCopy creates a copy of a tag, but uses the same attribute map!
 func (tag *Tag) ShallowCopy() *Tag {
 	var nt Tag
+	nt.kind = tag.kind
 	nt.name = tag.name
 	nt.content = tag.content
 	nt.lastContent = tag.lastContent
@@ -345,9 +611,41 @@ func (tag *Tag) ShallowCopy() *Tag {
 	nt.attrs = tag.attrs
 	nt.nextSibling = tag.nextSibling
 	nt.firstChild = tag.firstChild
+	nt.parent = tag.parent
+	nt.owner = tag.owner
 	return &nt
 }
 
+// DeepCopy creates a copy of a tag, with copies of all attributes, content and children.
+// The copy has no parent and no siblings, and can be modified and added anywhere.
+func (tag *Tag) DeepCopy() *Tag {
+	nt := NewTag(copyBytes(tag.name))
+	nt.kind = tag.kind
+	nt.content = copyBytes(tag.content)
+	nt.lastContent = copyBytes(tag.lastContent)
+	nt.xmlContent = copyBytes(tag.xmlContent)
+	for name, value := range tag.attrs {
+		nt.attrs[name] = copyBytes(value)
+	}
+	for child := tag.firstChild; child != nil; child = child.nextSibling {
+		nt.AddChild(child.DeepCopy())
+	}
+	return nt
+}
+
+// Clone is the same as DeepCopy
+func (tag *Tag) Clone() *Tag {
+	return tag.DeepCopy()
+}
+
+// copyBytes returns a copy of the given byte slice, or nil if it is nil
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
 // Bytes (previously getXMLRecursively) renders XML for a tag, recursively.
 // The generated XML is returned as a []byte.
 func (tag *Tag) Bytes() []byte {
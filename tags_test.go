@@ -0,0 +1,276 @@
+package tinysvg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func childNames(tag *Tag) string {
+	s := ""
+	for _, child := range tag.GetChildren() {
+		s += string(child.name)
+	}
+	return s
+}
+
+func TestDOM(t *testing.T) {
+	root := NewTag([]byte("g"))
+	a := root.AddNewTag([]byte("a"))
+	b := root.AddNewTag([]byte("b"))
+	c := root.AddNewTag([]byte("c"))
+	if a.Parent() != root || c.PrevSibling() != b || a.PrevSibling() != nil {
+		t.Fatal("wrong parent or sibling links")
+	}
+	if err := root.RemoveChild(b); err != nil {
+		t.Fatal(err)
+	}
+	if childNames(root) != "ac" || root.CountChildren() != 2 || root.LastChild() != c {
+		t.Fatalf("after RemoveChild: %s", childNames(root))
+	}
+	if err := root.RemoveChild(b); err != ErrNotAChild {
+		t.Fatalf("expected ErrNotAChild, got %v", err)
+	}
+	if err := root.InsertBefore(b, a); err != nil {
+		t.Fatal(err)
+	}
+	d := NewTag([]byte("d"))
+	if err := root.InsertAfter(d, a); err != nil {
+		t.Fatal(err)
+	}
+	if childNames(root) != "badc" {
+		t.Fatalf("after inserts: %s", childNames(root))
+	}
+	e := NewTag([]byte("e"))
+	if err := d.ReplaceWith(e); err != nil {
+		t.Fatal(err)
+	}
+	if childNames(root) != "baec" || d.Parent() != nil {
+		t.Fatalf("after ReplaceWith: %s", childNames(root))
+	}
+	// Moving a tag to the end changes the z-order
+	root.AddChild(b)
+	if childNames(root) != "aecb" || b.Index() != 3 || root.Child(1) != e {
+		t.Fatalf("after moving: %s", childNames(root))
+	}
+	c.Detach()
+	if childNames(root) != "aeb" || root.Child(5) != nil {
+		t.Fatalf("after Detach: %s", childNames(root))
+	}
+	if err := c.ReplaceWith(d); err != ErrNoParent {
+		t.Fatalf("expected ErrNoParent, got %v", err)
+	}
+	// A tag can not be added to itself or to one of its descendants
+	e.AddChild(c)
+	if err := c.InsertBefore(root, nil); err != ErrHierarchy {
+		t.Fatalf("expected ErrHierarchy, got %v", err)
+	}
+	if err := e.InsertAfter(root, c); err != ErrHierarchy {
+		t.Fatalf("expected ErrHierarchy, got %v", err)
+	}
+	if err := c.AddChild(c); err != ErrHierarchy {
+		t.Fatalf("expected ErrHierarchy, got %v", err)
+	}
+	if err := e.InsertBefore(e, c); err != ErrHierarchy {
+		t.Fatalf("expected ErrHierarchy, got %v", err)
+	}
+	if childNames(root) != "aeb" || c.Parent() != e || root.Parent() != nil {
+		t.Fatalf("after ErrHierarchy: %s", childNames(root))
+	}
+	empty := NewTag([]byte("g"))
+	if empty.LastChild() != nil || empty.CountChildren() != 0 {
+		t.Fatal("expected no children")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := NewTag([]byte("a"))
+	b := root.AddNewTag([]byte("b"))
+	b.AddNewTag([]byte("c"))
+	b.AddNewTag([]byte("d"))
+	e := root.AddNewTag([]byte("e"))
+	e.AddNewTag([]byte("f"))
+
+	s := ""
+	root.Walk(func(tag *Tag, depth int) WalkAction {
+		s += fmt.Sprintf("%s%d", tag.name, depth)
+		return WalkContinue
+	})
+	if s != "a0b1c2d2e1f2" {
+		t.Fatalf("pre-order: %s", s)
+	}
+
+	s = ""
+	root.WalkPostOrder(func(tag *Tag, depth int) WalkAction {
+		s += string(tag.name)
+		return WalkContinue
+	})
+	if s != "cdbfea" {
+		t.Fatalf("post-order: %s", s)
+	}
+
+	s = ""
+	stopped := !root.Walk(func(tag *Tag, depth int) WalkAction {
+		s += string(tag.name)
+		if tag == b {
+			return WalkSkipChildren
+		}
+		if tag == e {
+			return WalkStop
+		}
+		return WalkContinue
+	})
+	if s != "abe" || !stopped {
+		t.Fatalf("skip and stop: %s", s)
+	}
+
+	s = ""
+	it := b.Descendants()
+	for tag := it.Next(); tag != nil; tag = it.Next() {
+		s += string(tag.name)
+	}
+	if s != "cd" {
+		t.Fatalf("iterator: %s", s)
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	legend := svg.AddNewTag([]byte("g"))
+	box := legend.AddRect(0, 0, 10, 10)
+	box.Fill("red")
+	legend.AddText(15, 10, 12, "sans-serif", "Label")
+
+	entry := legend.DeepCopy()
+	if entry.Parent() != nil || entry.CountChildren() != 2 {
+		t.Fatal("the copy should have no parent and two children")
+	}
+	entry.FirstChild().Fill("blue")
+	entry.AddAttrib("transform", []byte("translate(0 20)"))
+	svg.AddChild(entry)
+	if string(box.Attrib("fill")) != "red" || legend.HasAttrib("transform") {
+		t.Fatal("changing the copy changed the original")
+	}
+	if svg.CountChildren() != 2 || entry.Document() != document {
+		t.Fatal("the copy was not added to the image")
+	}
+
+	copied := document.Clone()
+	copiedSVG, err := copied.GetTag([]byte("svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copiedSVG.FirstChild().Detach()
+	if svg.CountChildren() != 2 || copied.String() == document.String() {
+		t.Fatal("changing the copied document changed the original")
+	}
+	if copiedSVG.Document() != copied {
+		t.Fatal("the copied tags should belong to the copied document")
+	}
+}
+
+func TestNodes(t *testing.T) {
+	const input = `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1 Tiny//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11-tiny.dtd">` +
+		`<?xml-stylesheet href="style.css" type="text/css"?>` +
+		`<!-- generated -->` +
+		`<svg xmlns="http://www.w3.org/2000/svg" version="1.2" baseProfile="tiny">` +
+		`<!-- first --><rect width="1" height="1" />` +
+		`<script type="application/ecmascript"><![CDATA[if (a < b) {}]]></script>` +
+		`<g><!-- only a comment --></g>` +
+		`</svg>` +
+		`<!-- trailing -->`
+	document, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, child := range document.GetRoot().GetChildren() {
+		kinds = append(kinds, child.Kind().String())
+	}
+	if s := fmt.Sprint(kinds); s != "[doctype processing instruction comment element comment]" {
+		t.Fatalf("unexpected nodes: %s", s)
+	}
+	output := document.String()
+	if len(output) != len(input) {
+		t.Errorf("the output should be as long as the input, got:\n%s", output)
+	}
+	for _, part := range []string{"<!DOCTYPE svg PUBLIC", "><?xml-stylesheet href", "<!-- generated --><svg", "<!-- first --><rect", "<![CDATA[if (a < b) {}]]>", "<g><!-- only a comment --></g>", "</svg><!-- trailing -->"} {
+		if !strings.Contains(output, part) {
+			t.Errorf("expected the output to contain %s, got:\n%s", part, output)
+		}
+	}
+	svg, _ := document.GetTag([]byte("svg"))
+	if rect, err := svg.QuerySelector(":first-child"); err != nil || rect == nil || string(rect.Name()) != "rect" {
+		t.Error("comments should not count as children in selectors")
+	}
+	if _, err := document.GetTag([]byte("#comment")); err == nil {
+		t.Error("GetTag should only find elements")
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+	rect, _ := svg.GetTag([]byte("rect"))
+	rect.AddCDATA("text")
+	if issues := Validate(document); len(issues) != 1 {
+		t.Errorf("expected text in a rect to be reported, got %v", issues)
+	}
+	rect.RemoveChild(rect.FirstChild())
+
+	other := NewDocument([]byte{}, []byte(`<?xml version="1.0" encoding="UTF-8"?>`))
+	otherSVG := other.GetRoot().AddNewTag([]byte("svg"))
+	otherSVG.AddAttribMap(map[string][]byte{"xmlns": []byte(xmlNS), "version": []byte("1.2"), "baseProfile": []byte("tiny")})
+	otherSVG.AddNewTag([]byte("rect")).AddAttribMap(map[string][]byte{"width": []byte("1"), "height": []byte("1")})
+	otherSVG.Script("application/ecmascript", "if (a < b) {}")
+	otherSVG.AddNewTag([]byte("g"))
+	other.GetRoot().InsertChild(0, NewProcInst("xml-stylesheet", `href="style.css" type="text/css"`))
+	if diffs := DiffDocuments(document, other, 0); len(diffs) != 0 {
+		t.Errorf("comments should not be compared, got %v", diffs)
+	}
+	if string(document.CanonicalBytes()) != string(other.CanonicalBytes()) {
+		t.Errorf("comments should be left out of the canonical form:\n%s\n%s", document.CanonicalBytes(), other.CanonicalBytes())
+	}
+
+	comment := NewComment("a -- b-")
+	if s := string(comment.Bytes()); s != "<!--a - - b- -->" {
+		t.Errorf("unexpected comment: %s", s)
+	}
+	if s := string(NewProcInst("target", "").Bytes()); s != "<?target?>" {
+		t.Errorf("unexpected processing instruction: %s", s)
+	}
+
+	// Text between CDATA sections and comments stays in place
+	const mixed = `<svg xmlns="http://www.w3.org/2000/svg"><script><![CDATA[a<b]]> and <![CDATA[c]]></script><text>a<!-- b -->c<!-- d -->e</text></svg>`
+	document, err = Parse([]byte(mixed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := document.String(); s != mixed {
+		t.Errorf("the nodes were not kept in place:\n%s", s)
+	}
+	built := NewDocument([]byte{}, []byte{})
+	builtSVG := built.GetRoot().AddNewTag([]byte("svg"))
+	builtSVG.AddAttrib("xmlns", []byte(xmlNS))
+	script := builtSVG.AddNewTag([]byte("script"))
+	script.AddCDATA("a<b")
+	script.AddTextNode(" and ")
+	script.AddCDATA("c")
+	text := builtSVG.AddNewTag([]byte("text"))
+	text.AddContent([]byte("a"))
+	text.AddComment(" b ")
+	text.AddTextNode("c")
+	text.AddComment(" d ")
+	text.AddTextNode("e")
+	if s := built.String(); s != mixed {
+		t.Errorf("unexpected output:\n%s", s)
+	}
+	if diffs := DiffDocuments(document, built, 0); len(diffs) != 0 {
+		t.Errorf("unexpected differences: %v", diffs)
+	}
+	if s := string(document.CanonicalBytes()); s != `<svg xmlns="http://www.w3.org/2000/svg"><script>a&lt;b and c</script><text>ace</text></svg>` {
+		t.Errorf("unexpected canonical form: %s", s)
+	}
+	if s := NewTextNode("a & b").String(); s != "a &amp; b" || NewTextNode("").Kind() != TextNode {
+		t.Errorf("unexpected text node: %s", s)
+	}
+}
@@ -0,0 +1,200 @@
+package tinysvg
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// Values for TextAlign
+	AlignStart  = "start"
+	AlignCenter = "center"
+	AlignEnd    = "end"
+
+	// Values for DisplayAlign, in addition to AlignCenter
+	DisplayAlignAuto   = "auto"
+	DisplayAlignBefore = "before"
+	DisplayAlignAfter  = "after"
+
+	// Values for TextAnchor, in addition to AlignStart and AlignEnd
+	AnchorMiddle = "middle"
+
+	// Values for Baseline
+	BaselineAuto         = "auto"
+	BaselineAlphabetic   = "alphabetic"
+	BaselineMiddle       = "middle"
+	BaselineCentral      = "central"
+	BaselineHanging      = "hanging"
+	BaselineTextTop      = "text-top"
+	BaselineTextBottom   = "text-bottom"
+	BaselineIdeographic  = "ideographic"
+	BaselineMathematical = "mathematical"
+)
+
+// Span is a run of text with its own style, for use with Tspan, TextSpans and TextAreaSpans.
+// Empty fields are left out.
+type Span struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Color  *Color
+	Font   *Font
+	Style  *FontStyle
+	// BaselineShift is like "super", "sub" or "-3".
+	// Note that baseline-shift is not part of SVG Tiny 1.2, so Validate will report it.
+	BaselineShift string
+	// Break adds a tbreak element after the span. Only for spans in a textArea.
+	Break bool
+}
+
+// addFont sets the font-family and font-size attributes of a tag
+func (svg *Tag) addFont(f *Font) {
+	if f == nil {
+		return
+	}
+	if f.Family != "" {
+		svg.AddAttrib("font-family", []byte(f.Family))
+	}
+	if f.Size > 0 {
+		svg.AddAttrib("font-size", []byte(strconv.Itoa(f.Size)))
+	}
+}
+
+// SetFontStyle sets the font-size, font-weight, font-style, font-variant and letter-spacing attributes
+// of a text, tspan or textArea element, for the fields of the given style that are not empty.
+// A fractional size replaces the font-size that was set from a Font.
+func (svg *Tag) SetFontStyle(s *FontStyle) {
+	if s == nil {
+		return
+	}
+	if s.Size > 0 {
+		svg.AddAttrib("font-size", append([]byte(strconv.FormatFloat(s.Size, 'f', -1, 64)), s.Unit...))
+	}
+	for _, attr := range []struct{ name, value string }{
+		{"font-weight", s.Weight},
+		{"font-style", s.Style},
+		{"font-variant", s.Variant},
+	} {
+		if attr.value != "" {
+			svg.AddAttrib(attr.name, []byte(attr.value))
+		}
+	}
+	if s.LetterSpacing != 0 {
+		svg.AddAttrib("letter-spacing", []byte(strconv.FormatFloat(s.LetterSpacing, 'f', -1, 64)))
+	}
+}
+
+// Tspan adds a tspan element with the given text and style, to a text, textArea or tspan element
+func (svg *Tag) Tspan(s *Span) *Tag {
+	tspan := svg.AddNewTag([]byte("tspan"))
+	tspan.addFont(s.Font)
+	tspan.SetFontStyle(s.Style)
+	if s.Bold {
+		tspan.AddAttrib("font-weight", []byte("bold"))
+	}
+	if s.Italic {
+		tspan.AddAttrib("font-style", []byte("italic"))
+	}
+	if s.BaselineShift != "" {
+		tspan.AddAttrib("baseline-shift", []byte(s.BaselineShift))
+	}
+	tspan.Fill2(s.Color)
+	tspan.AddContent([]byte(s.Text))
+	if s.Break {
+		svg.Tbreak()
+	}
+	return tspan
+}
+
+// Tbreak adds a tbreak element, for an explicit line break in a textArea
+func (svg *Tag) Tbreak() *Tag {
+	return svg.AddNewTag([]byte("tbreak"))
+}
+
+// TextSpans adds a text element with several tspan elements, one for each span
+func (svg *Tag) TextSpans(p *Pos, f *Font, spans []*Span, c *Color) *Tag {
+	text := svg.AddNewTag([]byte("text"))
+	text.AddAttribMap(map[string][]byte{
+		"x": f2b(p.X),
+		"y": f2b(p.Y),
+	})
+	text.addFont(f)
+	text.Fill2(c)
+	for _, span := range spans {
+		text.Tspan(span)
+	}
+	return text
+}
+
+// TextArea adds a textArea element, where the text is wrapped automatically to fit the given width.
+// A width or height of 0 is rendered as "auto". Lines in the message are separated by tbreak elements.
+func (svg *Tag) TextArea(p *Pos, s *Size, f *Font, message string, c *Color) *Tag {
+	var spans []*Span
+	for _, line := range strings.Split(message, "\n") {
+		spans = append(spans, &Span{Text: line, Break: true})
+	}
+	if len(spans) > 0 {
+		spans[len(spans)-1].Break = false
+	}
+	return svg.TextAreaSpans(p, s, f, spans, c)
+}
+
+// TextAreaSpans adds a textArea element with several tspan elements, one for each span.
+// A width or height of 0 is rendered as "auto".
+func (svg *Tag) TextAreaSpans(p *Pos, s *Size, f *Font, spans []*Span, c *Color) *Tag {
+	textArea := svg.AddNewTag([]byte("textArea"))
+	autoOrSize := func(x float64) []byte {
+		if x == 0 {
+			return []byte("auto")
+		}
+		return f2b(x)
+	}
+	textArea.AddAttribMap(map[string][]byte{
+		"x":      f2b(p.X),
+		"y":      f2b(p.Y),
+		"width":  autoOrSize(s.W),
+		"height": autoOrSize(s.H),
+	})
+	textArea.addFont(f)
+	textArea.Fill2(c)
+	for _, span := range spans {
+		textArea.Tspan(span)
+	}
+	return textArea
+}
+
+// TextAlign sets the text-align attribute of a textArea, to "start", "center" or "end"
+func (svg *Tag) TextAlign(align string) {
+	svg.AddAttrib("text-align", []byte(align))
+}
+
+// DisplayAlign sets the display-align attribute of a textArea, to "auto", "before", "center" or "after"
+func (svg *Tag) DisplayAlign(align string) {
+	svg.AddAttrib("display-align", []byte(align))
+}
+
+// TextAnchor sets the text-anchor attribute of a text element, to "start", "middle" or "end".
+// Use "end" for right aligning text at its x position, and "middle" for centering it.
+func (svg *Tag) TextAnchor(anchor string) {
+	svg.AddAttrib("text-anchor", []byte(anchor))
+}
+
+// Baseline sets the dominant-baseline attribute of a text element, like "middle" or "hanging",
+// for aligning text vertically at its y position.
+// Note that dominant-baseline is not part of SVG Tiny 1.2, so Validate will report it.
+func (svg *Tag) Baseline(baseline string) {
+	svg.AddAttrib("dominant-baseline", []byte(baseline))
+}
+
+// TextAnchored adds a text element, with the given text-anchor ("start", "middle" or "end") and
+// dominant-baseline. An empty anchor or baseline is left out.
+func (svg *Tag) TextAnchored(p *Pos, f *Font, message string, c *Color, anchor, baseline string) *Tag {
+	text := svg.Text2(p, f, message, c)
+	if anchor != "" {
+		text.TextAnchor(anchor)
+	}
+	if baseline != "" {
+		text.Baseline(baseline)
+	}
+	return text
+}
@@ -0,0 +1,172 @@
+package tinysvg
+
+import (
+	"strconv"
+)
+
+// Change is a change of an attribute of a tag, that can be scheduled on a Timeline
+type Change struct {
+	Target        *Tag
+	Attribute     string  // like "x", "fill" or "transform"
+	From, To      string  // From may be empty, to animate from the current value
+	Dur           float64 // duration in seconds, 0 means that the attribute is set to To right away
+	TransformType string  // for the transform attribute: "translate", "scale", "rotate", "skewX" or "skewY"
+	Fill          string  // "freeze" (the default) keeps the new value after the animation, "remove" does not
+}
+
+// Cue is a Change that has been scheduled on a Timeline
+type Cue struct {
+	change  *Change
+	ref     *Cue    // the cue this cue is relative to, or nil if the time is absolute
+	event   string  // "begin" or "end" of the ref cue
+	offset  float64 // in seconds, from the start of the document or from the event
+	element *Tag    // the animation element, after compiling
+}
+
+// Timeline is used for scheduling changes to many tags at absolute or relative times.
+// When compiled, each change becomes an animation element with an id, and
+// changes that are relative to other changes get begin values like "id1.end+0.5s".
+type Timeline struct {
+	cues []*Cue
+}
+
+// NewTimeline creates a new and empty Timeline
+func NewTimeline() *Timeline {
+	return &Timeline{}
+}
+
+func (tl *Timeline) add(ref *Cue, event string, offset float64, change *Change) *Cue {
+	cue := &Cue{change: change, ref: ref, event: event, offset: offset}
+	tl.cues = append(tl.cues, cue)
+	return cue
+}
+
+// last returns the cue that was added last, or nil
+func (tl *Timeline) last() *Cue {
+	if len(tl.cues) == 0 {
+		return nil
+	}
+	return tl.cues[len(tl.cues)-1]
+}
+
+// At schedules a change at the given number of seconds after the document starts
+func (tl *Timeline) At(seconds float64, change *Change) *Cue {
+	return tl.add(nil, "", seconds, change)
+}
+
+// After schedules a change to begin the given number of seconds after the given cue ends
+func (tl *Timeline) After(cue *Cue, delay float64, change *Change) *Cue {
+	return tl.add(cue, "end", delay, change)
+}
+
+// With schedules a change to begin the given number of seconds after the given cue begins
+func (tl *Timeline) With(cue *Cue, delay float64, change *Change) *Cue {
+	return tl.add(cue, "begin", delay, change)
+}
+
+// Then schedules a change to begin the given number of seconds after the cue that was added last ends.
+// If there are no cues yet, the change begins the given number of seconds after the document starts.
+func (tl *Timeline) Then(delay float64, change *Change) *Cue {
+	if last := tl.last(); last != nil {
+		return tl.After(last, delay, change)
+	}
+	return tl.At(delay, change)
+}
+
+// Parallel schedules changes that begin at the same time, the given number of seconds
+// after the cue that was added last ends
+func (tl *Timeline) Parallel(delay float64, changes ...*Change) []*Cue {
+	return tl.Stagger(delay, 0, changes...)
+}
+
+// Stagger schedules changes that begin one after the other, with the given interval in seconds
+// between them. The first change begins the given number of seconds after the cue that was added last ends.
+func (tl *Timeline) Stagger(delay, interval float64, changes ...*Change) []*Cue {
+	var cues []*Cue
+	for i, change := range changes {
+		if i == 0 {
+			cues = append(cues, tl.Then(delay, change))
+			continue
+		}
+		cues = append(cues, tl.With(cues[0], float64(i)*interval, change))
+	}
+	return cues
+}
+
+// Element returns the animation element of a cue, or nil if the timeline has not been compiled yet
+func (cue *Cue) Element() *Tag {
+	return cue.element
+}
+
+// seconds formats a number of seconds as a clock value, like "1.5s"
+func seconds(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64) + "s"
+}
+
+// begin returns the begin value of a cue, like "2s" or "id1.end+0.5s".
+// The cue that this cue is relative to must have been compiled. Changes without a duration
+// become set elements without a dur attribute, that never end, so cues that are relative
+// to the end of such a change are relative to its begin instead.
+func (cue *Cue) begin() string {
+	if cue.ref == nil {
+		return seconds(cue.offset)
+	}
+	event := cue.event
+	if event == "end" && cue.ref.change.Dur <= 0 {
+		event = "begin"
+	}
+	begin := cue.ref.element.EnsureID() + "." + event
+	switch {
+	case cue.offset > 0:
+		begin += "+" + seconds(cue.offset)
+	case cue.offset < 0:
+		begin += "-" + seconds(-cue.offset)
+	}
+	return begin
+}
+
+// Compile adds an animation element to the target of each scheduled change, in the order they were scheduled.
+// Changes of color attributes become animateColor elements, changes of the transform attribute become
+// animateTransform elements and changes without a duration become set elements.
+// Cues that have already been compiled are skipped. Cues that other cues are relative to are compiled
+// first, even if they were scheduled on another timeline. Returns the new animation elements.
+func (tl *Timeline) Compile() []*Tag {
+	var elements []*Tag
+	for _, cue := range tl.cues {
+		elements = cue.compile(elements)
+	}
+	return elements
+}
+
+// compile adds the animation element of a cue, if it has not been compiled yet, after compiling
+// the cue that it is relative to. Returns the given elements, with the new animation elements appended.
+func (cue *Cue) compile(elements []*Tag) []*Tag {
+	if cue.element != nil {
+		return elements
+	}
+	if cue.ref != nil {
+		elements = cue.ref.compile(elements)
+	}
+	change := cue.change
+	timing := &Timing{Begin: cue.begin(), Fill: change.Fill}
+	if timing.Fill == "" {
+		timing.Fill = Freeze
+	}
+	interpolation := &Interpolation{From: change.From, To: change.To}
+	switch {
+	case change.Dur <= 0:
+		cue.element = change.Target.AnimateSet(change.Attribute, change.To, timing)
+	case change.TransformType != "":
+		timing.Dur = seconds(change.Dur)
+		cue.element = change.Target.AnimateTransform(change.TransformType, timing, interpolation)
+	case colorAttributes[change.Attribute]:
+		timing.Dur = seconds(change.Dur)
+		cue.element = change.Target.AnimateColor(change.Attribute, timing, interpolation)
+	default:
+		timing.Dur = seconds(change.Dur)
+		cue.element = change.Target.Animate(change.Attribute, timing, interpolation)
+	}
+	// Every animation element gets an id, so that other cues can refer to it
+	cue.element.EnsureID()
+	return append(elements, cue.element)
+}
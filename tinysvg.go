@@ -44,12 +44,30 @@ type (
 		Size   int
 	}
 
+	// FontStyle holds font attributes that can be set in addition to a Font, with SetFontStyle.
+	// Empty fields are left out.
+	FontStyle struct {
+		Size          float64 // a fractional size, used instead of the size of the Font if it is larger than 0
+		Unit          string  // the unit of Size, like "px", "pt" or "em"
+		Weight        string  // "normal", "bold", "bolder", "lighter" or "100" to "900"
+		Style         string  // "normal", "italic" or "oblique"
+		Variant       string  // "normal" or "small-caps"
+		LetterSpacing float64 // extra space between characters, not part of SVG Tiny 1.2
+	}
+
+	// Length is a number with an optional unit, like "10px" or "50%"
+	Length struct {
+		Value float64
+		Unit  string // "", "px", "pt", "pc", "mm", "cm", "in", "em", "ex" or "%"
+	}
+
 	YesNoAuto int
 )
 
 const (
 	xmlVersionEncoding = `<?xml version="1.0" encoding="UTF-8"?>`
 	xmlNS              = "http://www.w3.org/2000/svg"
+	xlinkNS            = "http://www.w3.org/1999/xlink"
 	svgTag             = "svg"
 	svgProfile         = "tiny"
 	svgVersion         = "1.2"
@@ -58,6 +76,8 @@ const (
 var (
 	ErrPair = errors.New("position pairs must be exactly two comma separated numbers")
 	sizeOne = Size{1.0, 1.0}
+
+	lengthUnits = []string{"px", "pt", "pc", "mm", "cm", "in", "em", "ex", "%"}
 )
 
 // NewTinySVG will create a new TinySVG document, where the width and height is defined in pixels, using the "px" suffix.
@@ -297,6 +317,118 @@ func (c *Color) Bytes() []byte {
 	return RGBABytes(c.R, c.G, c.B, c.A)
 }
 
+// ParseColor parses a color on the form "#rgb", "#rrggbb", "rgb(r, g, b)"
+// or "rgba(r, g, b, a)", where r, g and b are in the range 0..255 or 0%..100%.
+// Any other word, like "blue" or "none", is returned as a named color.
+func ParseColor(s string) (*Color, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid color: %q", s)
+	switch {
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return nil, invalid
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return nil, invalid
+		}
+		return RGB(int(rgb>>16), int((rgb>>8)&0xff), int(rgb&0xff)), nil
+	case strings.HasPrefix(s, "rgb(") || strings.HasPrefix(s, "rgba("):
+		if !strings.HasSuffix(s, ")") {
+			return nil, invalid
+		}
+		fields := strings.Split(s[strings.Index(s, "(")+1:len(s)-1], ",")
+		alpha := strings.HasPrefix(s, "rgba(")
+		if (alpha && len(fields) != 4) || (!alpha && len(fields) != 3) {
+			return nil, invalid
+		}
+		var rgb [3]int
+		for i := range rgb {
+			field := strings.TrimSpace(fields[i])
+			if strings.HasSuffix(field, "%") {
+				percentage, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+				if err != nil {
+					return nil, invalid
+				}
+				rgb[i] = int(percentage*255.0/100.0 + 0.5)
+				continue
+			}
+			x, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, invalid
+			}
+			rgb[i] = x
+		}
+		for _, x := range rgb {
+			if x < 0 || x > 255 {
+				return nil, invalid
+			}
+		}
+		if !alpha {
+			return RGB(rgb[0], rgb[1], rgb[2]), nil
+		}
+		a, err := strconv.ParseFloat(strings.TrimSpace(fields[3]), 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return RGBA(rgb[0], rgb[1], rgb[2], a), nil
+	}
+	if len(s) == 0 || strings.ContainsAny(s, " \t(),#") {
+		return nil, invalid
+	}
+	return ColorByName(s), nil
+}
+
+// ParseLength parses a number with an optional unit, like "10", "2.5em" or "50%"
+func ParseLength(s string) (*Length, error) {
+	s = strings.TrimSpace(s)
+	unit := ""
+	for _, u := range lengthUnits {
+		if strings.HasSuffix(s, u) {
+			unit = u
+			break
+		}
+	}
+	x, err := strconv.ParseFloat(strings.TrimSuffix(s, unit), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid length: %q", s)
+	}
+	return &Length{x, unit}, nil
+}
+
+// String returns the length as a string, like "10px"
+func (l *Length) String() string {
+	return string(f2b(l.Value)) + l.Unit
+}
+
+// AttribFloat returns the value of the given attribute as a float64
+func (svg *Tag) AttribFloat(attrName string) (float64, error) {
+	if !svg.HasAttrib(attrName) {
+		return 0, fmt.Errorf("no such attribute: %s", attrName)
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(svg.Attrib(attrName))), 64)
+}
+
+// AttribColor returns the value of the given attribute as a Color
+func (svg *Tag) AttribColor(attrName string) (*Color, error) {
+	if !svg.HasAttrib(attrName) {
+		return nil, fmt.Errorf("no such attribute: %s", attrName)
+	}
+	return ParseColor(string(svg.Attrib(attrName)))
+}
+
+// AttribLength returns the value of the given attribute as a Length
+func (svg *Tag) AttribLength(attrName string) (*Length, error) {
+	if !svg.HasAttrib(attrName) {
+		return nil, fmt.Errorf("no such attribute: %s", attrName)
+	}
+	return ParseLength(string(svg.Attrib(attrName)))
+}
+
 // --- Convenience functions and functions for backward compatibility ---
 
 func NewTinySVGi(x, y, w, h int) (*Document, *Tag) {
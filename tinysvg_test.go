@@ -2,7 +2,9 @@ package tinysvg
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSVG(t *testing.T) {
@@ -50,3 +52,663 @@ func TestWriteTo(t *testing.T) {
 		t.Fatalf("2: length is not 258 but %d\n", len(s))
 	}
 }
+
+func TestAttribs(t *testing.T) {
+	_, svg := NewTinySVG(256, 256)
+	rect := svg.Rect2(&Pos{1.5, 2}, &Size{10, 20}, RGB(255, 0, 0))
+	rect.AddAttrib("rx", []byte("2em"))
+	if x, err := rect.AttribFloat("x"); err != nil || x != 1.5 {
+		t.Fatalf("x is %v (%v)\n", x, err)
+	}
+	if c, err := rect.AttribColor("fill"); err != nil || c.R != 255 || c.G != 0 || c.A != OPAQUE {
+		t.Fatalf("fill is %v (%v)\n", c, err)
+	}
+	if l, err := rect.AttribLength("rx"); err != nil || l.Value != 2 || l.Unit != "em" {
+		t.Fatalf("rx is %v (%v)\n", l, err)
+	}
+	if _, err := rect.AttribFloat("missing"); err == nil {
+		t.Fatal("expected an error for a missing attribute")
+	}
+	rect.RemoveAttrib("rx")
+	attribs := rect.Attribs()
+	if len(attribs) != 5 || attribs[0].Name != "fill" || attribs[4].Name != "y" {
+		t.Fatalf("unexpected attributes: %v\n", attribs)
+	}
+	if string(rect.Name()) != "rect" {
+		t.Fatalf("unexpected name: %s\n", rect.Name())
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	for s, expected := range map[string]string{
+		"#f00":                   "#ff0000",
+		"#00FF7f":                "#00ff7f",
+		"rgb(1, 20, 30)":         "#01141e",
+		"rgba(1, 2, 3, 0.5)":     "rgba(1, 2, 3, 0.500000)",
+		"rgb(100%, 0%, 0%)":      "#ff0000",
+		"steelblue":              "steelblue",
+		"  currentColor  ":       "currentColor",
+		"rgba(255, 255, 255, 1)": "#ffffff",
+	} {
+		c, err := ParseColor(s)
+		if err != nil {
+			t.Fatalf("could not parse %q: %v\n", s, err)
+		}
+		if string(c.Bytes()) != expected {
+			t.Fatalf("%q was parsed as %s, expected %s\n", s, c.Bytes(), expected)
+		}
+	}
+	for _, s := range []string{"", "#12", "#ggg", "rgb(1, 2)", "rgba(1, 2, 3, x)", "rgb(300, 0, 0)", "rgb(0, -1, 0)", "rgb(0, 0, 101%)", "not a color"} {
+		if _, err := ParseColor(s); err == nil {
+			t.Fatalf("expected an error for %q\n", s)
+		}
+	}
+}
+
+func TestIDs(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	document.SetIDPrefix("shape")
+	gradient := svg.AddNewTag([]byte("linearGradient"))
+	gradient.SetID("shape1")
+	rect := svg.AddRect(0, 0, 10, 10)
+	rect.AddAttrib("fill", []byte("url(#shape1)"))
+	use := svg.AddNewTag([]byte("use"))
+	use.AddAttrib("xlink:href", []byte("#shape1"))
+	if id := rect.EnsureID(); id != "shape2" {
+		t.Fatalf("expected shape2, got %s\n", id)
+	}
+	if target, err := document.ResolveAttrib(rect, "fill"); err != nil || target != gradient {
+		t.Fatalf("could not resolve fill: %v\n", err)
+	}
+	if target, err := document.ResolveAttrib(use, "xlink:href"); err != nil || target != gradient {
+		t.Fatalf("could not resolve xlink:href: %v\n", err)
+	}
+	if len(document.DuplicateIDs()) != 0 {
+		t.Fatal("expected no duplicate ids")
+	}
+	use.SetID("shape1")
+	if tags := document.DuplicateIDs()["shape1"]; len(tags) != 2 {
+		t.Fatalf("expected shape1 to be a duplicate id, got %v\n", tags)
+	}
+	use.RemoveAttrib("id")
+	document.PrefixIDs("a-")
+	if string(rect.Attrib("fill")) != "url(#a-shape1)" || string(use.Attrib("xlink:href")) != "#a-shape1" {
+		t.Fatalf("references were not updated: %s %s\n", rect.Attrib("fill"), use.Attrib("xlink:href"))
+	}
+	if _, err := document.GetElementByID("a-shape2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := document.GetElementByID("shape2"); err == nil {
+		t.Fatal("expected shape2 to be renamed")
+	}
+
+	// An id that is the prefixed form of another id is only renamed once
+	for i := 0; i < 20; i++ {
+		document, svg := NewTinySVG(100, 100)
+		svg.AddNewTag([]byte("linearGradient")).SetID("x")
+		svg.AddNewTag([]byte("linearGradient")).SetID("px")
+		rect := svg.AddRect(0, 0, 10, 10)
+		rect.AddAttrib("fill", []byte("url(#x) red"))
+		rect.AddAttrib("stroke", []byte("url(#px) url(#missing)"))
+		document.PrefixIDs("p")
+		if s := string(rect.Attrib("fill")); s != "url(#px) red" {
+			t.Fatalf("expected url(#px) red, got %s\n", s)
+		}
+		if s := string(rect.Attrib("stroke")); s != "url(#ppx) url(#missing)" {
+			t.Fatalf("expected url(#ppx) url(#missing), got %s\n", s)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	document, svg := NewTinySVG(256, 256)
+	svg.Describe("Fish &amp; chips")
+	svg.AddRoundedRect(30, 10, 5, 5, 20, 20).Fill("red")
+	parsed, err := Parse(document.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffDocuments(document, parsed, 0); len(diffs) != 0 {
+		t.Fatalf("unexpected differences: %v\n", diffs)
+	}
+	if len(parsed.String()) != len(document.String()) {
+		t.Fatalf("length is not %d but %d\n", len(document.String()), len(parsed.String()))
+	}
+
+	// Text between child elements stays in place
+	const mixed = `<svg xmlns="http://www.w3.org/2000/svg"><text>Hello <tspan>x</tspan> world <tspan>y</tspan> end</text></svg>`
+	parsed, err = Parse([]byte(mixed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := parsed.String(); s != mixed {
+		t.Fatalf("mixed content was not kept in place:\n%s\n", s)
+	}
+	moved, err := Parse([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><text>Hello <tspan>x</tspan><tspan>y</tspan> world  end</text></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffDocuments(parsed, moved, 0); len(diffs) != 2 {
+		t.Fatalf("expected the moved text to be reported, got %v\n", diffs)
+	}
+	if string(parsed.CanonicalBytes()) == string(moved.CanonicalBytes()) {
+		t.Fatal("expected the moved text to change the canonical form")
+	}
+	if chars := parsed.TextCharacters(); chars != " Hdelnorwxy" {
+		t.Fatalf("unexpected characters: %q\n", chars)
+	}
+
+	for _, invalid := range []string{"", "<svg>", "<svg></g>", "<svg/><svg/>", "text<svg/>"} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Fatalf("expected an error for %q\n", invalid)
+		}
+	}
+}
+
+func TestCanonicalBytes(t *testing.T) {
+	a, svgA := NewTinySVG(100, 100)
+	svgA.Rect2(&Pos{1.5, 2}, &Size{10, 20}, RGB(255, 0, 0))
+	svgA.AddContent([]byte("\n  "))
+
+	b, svgB := NewTinySVG(100, 100)
+	rect := svgB.AddNewTag([]byte("rect"))
+	rect.AddAttrib("y", []byte(" 2.000000"))
+	rect.AddAttrib("x", []byte("1.50"))
+	rect.AddAttrib("height", []byte("2e1"))
+	rect.AddAttrib("width", []byte("10"))
+	rect.AddAttrib("fill", []byte("#FF0000"))
+	rect.AddAttrib("id", []byte("shape-01"))
+	rectA, _ := svgA.GetTag([]byte("rect"))
+	rectA.AddAttrib("id", []byte("shape-01"))
+
+	ca, cb := string(a.CanonicalBytes()), string(b.CanonicalBytes())
+	if ca != cb {
+		t.Fatalf("expected the same canonical form:\n%s\n%s\n", ca, cb)
+	}
+	const expected = `<svg baseProfile="tiny" height="100px" version="1.2" viewBox="0 0 100 100" width="100px" xmlns="http://www.w3.org/2000/svg"><rect fill="#f00" height="20" id="shape-01" width="10" x="1.5" y="2"/></svg>`
+	if ca != xmlVersionEncoding+expected {
+		t.Fatalf("unexpected canonical form:\n%s\n", ca)
+	}
+
+	for value, expected := range map[string]string{
+		"M 1.000000 2.500000 L -3.0 4": "M 1 2.5 L -3 4",
+		"10.50px":                      "10.5px",
+		"#00ff00":                      "#00ff00",
+		"a1 b2":                        "a1 b2",
+		"translate(1.0, -0)":           "translate(1, 0)",
+	} {
+		if s := canonicalNumbers(value); s != expected {
+			t.Fatalf("%q became %q, expected %q\n", value, s, expected)
+		}
+	}
+	for value, expected := range map[string]string{
+		"M10.000,20.000L30.50 40":        "M10 20L30.5 40",
+		"M 10 20 L 30.5 40 z":            "M10 20L30.5 40z",
+		"m10-20.0 1e1.5":                 "m10 -20 10 0.5",
+		"translate(1.0, -0) scale( 2 )":  "translate(1 0) scale(2)",
+		"rotate(45),translate(10,20.00)": "rotate(45) translate(10 20)",
+		"10,20 30.0,40":                  "10 20 30 40",
+	} {
+		if s := canonicalCommands(value); s != expected {
+			t.Fatalf("%q became %q, expected %q\n", value, s, expected)
+		}
+	}
+	if canonicalValue("d", []byte("M10.0 20")) != canonicalValue("d", []byte(" M 10 , 20 ")) {
+		t.Fatal("expected compact and spaced path data to have the same canonical form")
+	}
+	for name, value := range map[string]string{
+		"id":          "shape-01",
+		"aria-label":  "Version  2.0",
+		"font-family": "Foo 3.10",
+		"xlink:href":  "#a-01",
+	} {
+		if s := canonicalValue(name, []byte(value)); s != value {
+			t.Fatalf("the value of %s was changed from %q to %q\n", name, value, s)
+		}
+	}
+}
+
+func TestAnimate(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	document.SetStrict(true)
+	spinner := svg.Circle(50, 50, 10, "blue")
+	spinner.AnimateTransform("rotate", &Timing{Dur: "1s", RepeatCount: Indefinite}, &Interpolation{From: "0 50 50", To: "360 50 50"})
+	opacity := spinner.Animate("fill-opacity", &Timing{Begin: "0s", Dur: "2s", Fill: Freeze}, &Interpolation{
+		Values:     []string{"0", "1", "0"},
+		KeyTimes:   []float64{0, 0.5, 1},
+		KeySplines: [][4]float64{{0.5, 0, 0.5, 1}, {0.5, 0, 0.5, 1}},
+		CalcMode:   CalcSpline,
+	})
+	spinner.AnimateColor("fill", &Timing{Dur: "3s"}, &Interpolation{From: "blue", To: "red"})
+	spinner.AnimateSet("visibility", "hidden", &Timing{Begin: "5s"})
+	track := svg.AddNewTag([]byte("path"))
+	track.AddAttrib("d", []byte("M 0 0 L 100 100"))
+	svg.Circle(0, 0, 2, "red").AnimateMotionAlong(track, RotateAuto, &Timing{Dur: "4s"}, nil)
+
+	if s := string(opacity.Attrib("keySplines")); s != "0.500000 0 0.500000 1;0.500000 0 0.500000 1" {
+		t.Fatalf("unexpected keySplines: %s\n", s)
+	}
+	if s := string(opacity.Attrib("keyTimes")); s != "0;0.500000;1" {
+		t.Fatalf("unexpected keyTimes: %s\n", s)
+	}
+	mpath, err := document.QuerySelector("animateMotion > mpath")
+	if err != nil || mpath == nil {
+		t.Fatalf("no mpath: %v\n", err)
+	}
+	if target, err := document.ResolveAttrib(mpath, "xlink:href"); err != nil || target != track {
+		t.Fatalf("the mpath does not refer to the track: %v\n", err)
+	}
+	if !strings.Contains(document.String(), `xmlns:xlink="`+xlinkNS+`"`) {
+		t.Fatal("the xlink namespace is not declared")
+	}
+	if errs := document.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected strict mode errors: %v\n", errs)
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	box := svg.Box(0, 0, 10, 10, "red")
+	dots := []*Tag{svg.Circle(10, 50, 5, "blue"), svg.Circle(30, 50, 5, "blue"), svg.Circle(50, 50, 5, "blue")}
+
+	tl := NewTimeline()
+	move := tl.At(1, &Change{Target: box, Attribute: "x", From: "0", To: "90", Dur: 2})
+	tl.With(move, 0.5, &Change{Target: box, Attribute: "fill", To: "green", Dur: 1})
+	var changes []*Change
+	for _, dot := range dots {
+		changes = append(changes, &Change{Target: dot, Attribute: "r", To: "8", Dur: 0.5})
+	}
+	staggered := tl.Stagger(0.5, 0.25, changes...)
+	hide := tl.Then(0, &Change{Target: box, Attribute: "visibility", To: "hidden"})
+	elements := tl.Compile()
+
+	if len(elements) != 6 || len(tl.Compile()) != 0 {
+		t.Fatalf("expected 6 animation elements, got %d\n", len(elements))
+	}
+	moveID := move.Element().ID()
+	for cue, expected := range map[*Cue]string{
+		move:         "1s",
+		tl.cues[1]:   moveID + ".begin+0.5s",
+		staggered[0]: tl.cues[1].Element().ID() + ".end+0.5s",
+		staggered[2]: staggered[0].Element().ID() + ".begin+0.5s",
+		hide:         staggered[2].Element().ID() + ".end",
+	} {
+		if begin := string(cue.Element().Attrib("begin")); begin != expected {
+			t.Fatalf("expected begin to be %s, got %s\n", expected, begin)
+		}
+	}
+	if string(tl.cues[1].Element().Name()) != "animateColor" || string(hide.Element().Name()) != "set" {
+		t.Fatal("wrong animation elements")
+	}
+	// Changes without a duration never end, so the next cue begins relative to their begin
+	instant := NewTimeline()
+	visible := instant.At(0, &Change{Target: box, Attribute: "visibility", To: "visible"})
+	grow := instant.Then(1, &Change{Target: box, Attribute: "width", To: "20", Dur: 1})
+	instant.Compile()
+	if begin := string(grow.Element().Attrib("begin")); begin != visible.Element().ID()+".begin+1s" {
+		t.Fatalf("expected the change after a set element to begin relative to its begin, got %s\n", begin)
+	}
+	// Cues that are relative to cues that have not been compiled, on another timeline
+	first, second := NewTimeline(), NewTimeline()
+	fade := first.At(0, &Change{Target: box, Attribute: "opacity", To: "0", Dur: 1})
+	show := second.After(fade, 0, &Change{Target: box, Attribute: "visibility", To: "visible"})
+	if elements := second.Compile(); len(elements) != 2 || elements[0] != fade.Element() || len(first.Compile()) != 0 {
+		t.Fatal("expected the cue on the other timeline to be compiled first")
+	}
+	if begin := string(show.Element().Attrib("begin")); begin != fade.Element().ID()+".end" {
+		t.Fatalf("unexpected begin: %s\n", begin)
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+}
+
+func TestTextSpans(t *testing.T) {
+	document, svg := NewTinySVG(200, 100)
+	text := svg.TextSpans(&Pos{10, 20}, &Font{"sans-serif", 12}, []*Span{
+		{Text: "Status: "},
+		{Text: "OK", Bold: true, Color: ColorByName("green")},
+	}, nil)
+	if text.CountChildren() != 2 || string(text.Child(1).Attrib("font-weight")) != "bold" {
+		t.Fatal("wrong tspan elements")
+	}
+	area := svg.TextArea(&Pos{10, 40}, &Size{100, 0}, &Font{"serif", 10}, "first line\nsecond line", ColorByName("black"))
+	area.TextAlign(AlignCenter)
+	area.DisplayAlign(DisplayAlignBefore)
+	if s := string(area.Attrib("height")); s != "auto" {
+		t.Fatalf("expected the height to be auto, got %s\n", s)
+	}
+	s := ""
+	for _, child := range area.GetChildren() {
+		s += string(child.Name()) + " "
+	}
+	if s != "tspan tbreak tspan " {
+		t.Fatalf("unexpected children: %s\n", s)
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+	svg.TextSpans(&Pos{0, 0}, nil, []*Span{{Text: "2", BaselineShift: "super"}}, nil)
+	if issues := Validate(document); len(issues) != 1 {
+		t.Fatalf("expected baseline-shift to be reported, got %v\n", issues)
+	}
+}
+
+func TestFontAttributes(t *testing.T) {
+	document, svg := NewTinySVG(200, 100)
+	label := svg.TextAnchored(&Pos{190, 20}, &Font{"serif", 10}, "42", nil, AlignEnd, "")
+	label.SetFontStyle(&FontStyle{Size: 10.5, Unit: "px", Weight: "bold", Style: "italic", Variant: "small-caps"})
+	for attr, value := range map[string]string{
+		"font-size":    "10.5px",
+		"font-weight":  "bold",
+		"font-style":   "italic",
+		"font-variant": "small-caps",
+		"text-anchor":  "end",
+	} {
+		if s := string(label.Attrib(attr)); s != value {
+			t.Errorf("expected %s to be %s, got %s\n", attr, value, s)
+		}
+	}
+	if label.HasAttrib("dominant-baseline") || label.HasAttrib("letter-spacing") {
+		t.Error("empty options should be left out")
+	}
+	spans := svg.TextSpans(&Pos{0, 0}, nil, []*Span{{Text: "light", Style: &FontStyle{Weight: "300"}}}, nil)
+	if s := string(spans.FirstChild().Attrib("font-weight")); s != "300" {
+		t.Errorf("expected 300, got %s\n", s)
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+	if s := string(svg.AddText(0, 0, 12, "sans-serif", "old").Attrib("font-size")); s != "12" {
+		t.Errorf("expected 12, got %s\n", s)
+	}
+	if w := MeasureStyledText(&Font{"monospace", 10}, &FontStyle{LetterSpacing: 1}, "ab"); w != 14 {
+		t.Errorf("expected 14, got %v\n", w)
+	}
+}
+
+func TestTextAsPath(t *testing.T) {
+	for r := rune(32); r < 127; r++ {
+		if _, ok := strokeGlyphs[r]; !ok {
+			t.Errorf("missing glyph for %q\n", r)
+		}
+	}
+	document, svg := NewTinySVG(100, 100)
+	path := svg.TextAsPath(&Pos{10, 20}, 12, "HI", ColorByName("black"))
+	if s := string(path.Attrib("d")); s != "M10 12 L10 20 M14 12 L14 20 M10 16 L14 16 M16 12 L18 12 M17 12 L17 20 M16 20 L18 20" {
+		t.Errorf("unexpected path data: %s\n", s)
+	}
+	if w := StrokeTextWidth(12, "HI\nH"); w != 8 {
+		t.Errorf("expected 8, got %v\n", w)
+	}
+	if s := string(svg.TextAsPath(&Pos{0, 50}, 10, "Tiny 1.2, {ok}? ✓", nil).Attrib("stroke")); s != "currentColor" {
+		t.Errorf("expected currentColor, got %s\n", s)
+	}
+	if svg.TextAsPath(&Pos{0, 80}, 10, " \n ", nil) != nil || svg.CountChildren() != 2 {
+		t.Error("expected no path for text without strokes")
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+}
+
+func TestEmbedFont(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	f := NewSVGFont("Blocks", 1000, 800, 200, 600)
+	f.SetMissingGlyph("M0 0H500V700H0Z", 0)
+	f.AddGlyph("A", "M0 0L250 700L500 0Z", 0)
+	f.AddGlyph("B", "M0 0H500V700H0Z", 550)
+	f.AddGlyph("<", "M500 0L0 350L500 700Z", 0)
+	f.AddGlyph("fi", "M0 0H900V700H0Z", 900)
+	svg.Text2(&Pos{10, 50}, f.Font(20), "A &lt;", nil)
+	font := document.EmbedFont(f)
+	if svg.FirstChild() != font || font.ID() == "" {
+		t.Fatal("the font should be the first child of the svg element, with an id")
+	}
+	var glyphs []string
+	for _, child := range font.GetChildren() {
+		glyphs = append(glyphs, string(child.Name())+":"+string(child.Attrib("unicode")))
+	}
+	if s := strings.Join(glyphs, " "); s != "font-face: missing-glyph: glyph:A glyph:&lt;" {
+		t.Errorf("unexpected glyphs: %s\n", s)
+	}
+	if s := string(font.FirstChild().Attrib("font-family")); s != "Blocks" {
+		t.Errorf("expected the Blocks font family, got %s\n", s)
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+}
+
+func TestLink(t *testing.T) {
+	document, svg := NewTinySVG(200, 100)
+	a := svg.LinkTarget("https://example.com/?a=1&b=2", TargetBlank)
+	a.Box(10, 10, 50, 30, "red")
+	if !strings.Contains(document.String(), `xmlns:xlink="`+xlinkNS+`"`) {
+		t.Fatal("the xlink namespace should be declared on the svg element")
+	}
+	if s := string(a.Attrib("xlink:href")); s != "https://example.com/?a=1&amp;b=2" {
+		t.Errorf("the href should be escaped, got %s\n", s)
+	}
+	first := svg.Box(100, 10, 50, 30, "blue")
+	second := svg.Box(100, 50, 50, 30, "green")
+	link, err := first.WrapInLink("#top", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Parent() != link || link.Index() != 1 || second.Index() != 2 || link.HasAttrib("target") {
+		t.Fatal("the box should have been wrapped in place")
+	}
+	if _, err := NewTag([]byte("rect")).WrapInLink("#top", ""); err != ErrNoParent {
+		t.Errorf("expected ErrNoParent, got %v\n", err)
+	}
+	svg.SetID("top")
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	document.RegisterNamespace("my", "https://example.com/ns")
+	rect := svg.Box(0, 0, 10, 10, "red")
+	rect.AddAttrib("my:label", []byte("box"))
+	if err := rect.AddAttribNS(xlinkNS, "title", []byte("A box")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rect.AddAttribNS("https://example.com/unknown", "x", nil); err != ErrUnknownNamespace {
+		t.Errorf("expected ErrUnknownNamespace, got %v\n", err)
+	}
+	svg.AddNewTag([]byte("other:thing"))
+	if unknown := document.DeclareNamespaces(); len(unknown) != 1 || unknown[0] != "other" {
+		t.Errorf("expected the other prefix to be unknown, got %v\n", unknown)
+	}
+	output := document.String()
+	for _, declaration := range []string{`xmlns:my="https://example.com/ns"`, `xmlns:xlink="` + xlinkNS + `"`} {
+		if !strings.Contains(output, declaration) {
+			t.Errorf("expected %s to be declared\n", declaration)
+		}
+	}
+	if strings.Contains(output, "xmlns:dc") {
+		t.Error("unused prefixes should not be declared")
+	}
+
+	parsed, err := Parse([]byte(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:x="http://purl.org/dc/elements/1.1/"><metadata><x:title>T</x:title></metadata></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	title, err := parsed.GetTag([]byte("dc:title"))
+	if err != nil || string(title.Name()) != "x:title" || title.NamespaceURI() != dcNS {
+		t.Fatalf("expected to find x:title as dc:title, got %v\n", err)
+	}
+	if parsed.NamespaceURI("x") != dcNS {
+		t.Error("the x prefix should have been registered by the parser")
+	}
+	if s := string(rect.AttribNS("https://example.com/ns", "label")); s != "box" {
+		t.Errorf("expected box, got %s\n", s)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	document.SetTitle("Sales & costs")
+	svg.Describe("Quarterly numbers")
+	m := &Metadata{
+		Title:    "Sales",
+		Creator:  "Jane Doe",
+		Date:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		License:  "https://example.com/license?a=1&b=2",
+		Keywords: []string{"sales", "Q1"},
+	}
+	svg.AddMetadata(m)
+	output := document.String()
+	if !strings.Contains(output, "><title>Sales &amp; costs</title><desc>") || svg.FirstChild().LocalName() == "title" {
+		t.Error("the title of the document should be rendered as the first child of the svg element")
+	}
+	for _, prefix := range []string{"rdf", "dc", "cc"} {
+		if !strings.Contains(output, "xmlns:"+prefix+"=") {
+			t.Errorf("expected the %s namespace to be declared\n", prefix)
+		}
+		if svg.HasAttrib("xmlns:" + prefix) {
+			t.Errorf("rendering should not declare the %s namespace on the svg element\n", prefix)
+		}
+	}
+	document.SetTitle("Sales")
+	document.EmbedFont(NewSVGFont("Test", 1000, 800, -200, 500))
+	if output := document.String(); strings.Count(output, "<title>") != 1 || !strings.Contains(output, "<title>Sales</title><font") {
+		t.Errorf("expected exactly one title element, with the new title, got:\n%s\n", output)
+	}
+	svg.Title("Old & new")
+	if output := document.String(); !strings.Contains(output, "<title>Old &amp; new</title>") {
+		t.Errorf("expected the title to be escaped, got:\n%s\n", output)
+	}
+	document.SetTitle("New")
+	if output := document.String(); strings.Count(output, "<title>") != 1 || !strings.Contains(output, "<title>New</title>") {
+		t.Errorf("expected the existing title element to get the new title, got:\n%s\n", output)
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+	parsed, err := Parse(document.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := parsed.Metadata()
+	if read == nil || read.Title != m.Title || read.Creator != m.Creator || !read.Date.Equal(m.Date) || read.License != m.License || strings.Join(read.Keywords, ",") != "sales,Q1" {
+		t.Errorf("unexpected metadata: %+v\n", read)
+	}
+}
+
+func TestAccessibility(t *testing.T) {
+	document, svg := NewTinySVG(200, 100)
+	chart := svg.AddNewTag([]byte("g"))
+	chart.Role("img")
+	bar := chart.Box(10, 10, 20, 50, "blue")
+	desc := chart.DescribedBy("Sales per quarter")
+	title := chart.LabelledBy("Sales & costs")
+	if chart.FirstChild() != desc || chart.Child(1) != title || chart.Child(2) != bar {
+		t.Fatal("the title and desc elements should be added before the other children")
+	}
+	if string(chart.Attrib("aria-labelledby")) != title.ID() || string(chart.Attrib("aria-describedby")) != desc.ID() {
+		t.Fatal("the aria attributes should refer to the title and desc elements")
+	}
+	if s := string(title.Content()); s != "Sales &amp; costs" {
+		t.Errorf("the title should be escaped, got %s\n", s)
+	}
+	label := svg.AddNewTag([]byte("g"))
+	label.AriaLabel(`"Profit" & <loss>`)
+	if s := string(label.Attrib("aria-label")); s != "&quot;Profit&quot; &amp; &lt;loss&gt;" {
+		t.Errorf("the aria-label attribute should be escaped, got %s\n", s)
+	}
+	other := chart.Box(40, 10, 20, 50, "red")
+	FocusOrder(bar, other)
+	if string(bar.Attrib("nav-next")) != "url(#"+other.ID()+")" || string(other.Attrib("nav-prev")) != "url(#"+bar.ID()+")" {
+		t.Fatal("wrong focus navigation")
+	}
+	document.PrefixIDs("a-")
+	if s := string(chart.Attrib("aria-labelledby")); s != title.ID() || !strings.HasPrefix(s, "a-") {
+		t.Errorf("the aria-labelledby attribute should have been updated, got %s\n", s)
+	}
+	issues := Validate(document)
+	if len(issues) != 3 {
+		t.Fatalf("expected the three aria attributes to be reported, got %v\n", issues)
+	}
+	oldID := title.ID()
+	title.SetID("renamed")
+	for _, issue := range Validate(document) {
+		if strings.Contains(issue.Message, oldID) {
+			return
+		}
+	}
+	t.Error("expected the missing id to be reported")
+}
+
+func TestEvents(t *testing.T) {
+	document, svg := NewTinySVG(200, 100)
+	button := svg.Box(10, 10, 50, 20, "gray")
+	button.Handler(EventActivate, `alert("a]]>b")`)
+	shared := svg.Handler("", "evt.target.setTraitRGBColor('fill', 255, 0, 0)")
+	other := svg.Box(70, 10, 50, 20, "gray")
+	listener := other.AddListener(EventClick, shared)
+	if listener.Parent() != svg || string(listener.Attrib("observer")) != other.ID() || string(listener.Attrib("handler")) != "#"+shared.ID() {
+		t.Fatal("wrong listener")
+	}
+	if !strings.Contains(document.String(), `xmlns:ev="`+evNS+`"`) {
+		t.Fatal("the ev namespace should be declared")
+	}
+	if s := string(button.FirstChild().FirstChild().Bytes()); s != `<![CDATA[alert("a]]]]><![CDATA[>b")]]>` {
+		t.Errorf("wrong CDATA wrapping: %s\n", s)
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+	document.PrefixIDs("p-")
+	if string(listener.Attrib("observer")) != other.ID() || string(listener.Attrib("handler")) != "#"+shared.ID() {
+		t.Fatalf("the listener was not updated: %s\n", listener.Bytes())
+	}
+	other.SetID("renamed")
+	if issues := Validate(document); len(issues) != 1 || !strings.Contains(issues[0].Message, "observer") {
+		t.Fatalf("expected the missing observer to be reported, got %v\n", issues)
+	}
+	other.SetID(string(listener.Attrib("observer")))
+	parsed, err := Parse(document.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := parsed.GetElementByID(shared.ID())
+	if err != nil || handler.FirstChild().Kind() != CDATANode || string(handler.FirstChild().Content()) != "evt.target.setTraitRGBColor('fill', 255, 0, 0)" {
+		t.Errorf("the handler code should survive parsing, got %s\n", handler.Bytes())
+	}
+}
+
+func TestScript(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	script := svg.Script(scriptTypeECMA, "if (a < b && c) { x = ']]>' }")
+	if script.CountChildren() != 1 || script.FirstChild().Kind() != CDATANode || script.FirstChild().isElement() {
+		t.Fatal("expected a CDATA node")
+	}
+	output := document.String()
+	if !strings.Contains(output, "<![CDATA[if (a < b && c) { x = ']]]]><![CDATA[>' }]]></script>") {
+		t.Errorf("wrong script output: %s\n", output)
+	}
+	parsed, err := Parse([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffDocuments(document, parsed, 0); len(diffs) != 0 {
+		t.Errorf("unexpected differences: %v\n", diffs)
+	}
+	if !bytes.Equal(document.CanonicalBytes(), parsed.CanonicalBytes()) {
+		t.Error("the canonical form should be the same after parsing")
+	}
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v\n", issues)
+	}
+	copied := script.DeepCopy()
+	if copied.FirstChild().Kind() != CDATANode {
+		t.Error("the kind of node should be copied")
+	}
+}
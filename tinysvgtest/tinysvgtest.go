@@ -0,0 +1,75 @@
+// Package tinysvgtest has helpers for testing code that generates images with tinysvg
+package tinysvgtest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/tinysvg"
+)
+
+// Update makes AssertGolden write the golden files instead of comparing with them.
+// The package does not register any flags, so a test package can set this from its own flag:
+//
+//	var update = flag.Bool("update", false, "update the golden files")
+//
+//	func TestMain(m *testing.M) {
+//		flag.Parse()
+//		tinysvgtest.Update = *update
+//		os.Exit(m.Run())
+//	}
+var Update bool
+
+// tolerance is how much numbers may differ when comparing with a golden file
+const tolerance = 1e-9
+
+// AssertGolden renders the given image and compares it with the given golden file.
+// If Update is true, the golden file is written instead.
+// On failure, the structural differences between the golden file and the image are reported.
+func AssertGolden(t testing.TB, image *tinysvg.Document, filename string) {
+	t.Helper()
+	actual := Normalize(image)
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatalf("could not create the directory for %s: %v", filename, err)
+		}
+		if err := os.WriteFile(filename, actual, 0644); err != nil {
+			t.Fatalf("could not write %s: %v", filename, err)
+		}
+		return
+	}
+	golden, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("could not read %s (set Update to create it): %v", filename, err)
+	}
+	if bytes.Equal(bytes.TrimSpace(golden), bytes.TrimSpace(actual)) {
+		return
+	}
+	goldenImage, err := tinysvg.Parse(golden)
+	if err != nil {
+		t.Fatalf("could not parse %s: %v", filename, err)
+	}
+	actualImage, err := tinysvg.Parse(actual)
+	if err != nil {
+		t.Fatalf("could not parse the rendered image: %v", err)
+	}
+	diffs := tinysvg.DiffDocuments(goldenImage, actualImage, tolerance)
+	if len(diffs) == 0 {
+		return
+	}
+	var lines []string
+	for _, diff := range diffs {
+		lines = append(lines, "  "+diff.String())
+	}
+	t.Errorf("the image differs from %s (set Update to update it):\n%s", filename, strings.Join(lines, "\n"))
+}
+
+// Normalize renders the given image as canonical XML, followed by a newline.
+// Images that only differ in attribute order or in how numbers and colors
+// are formatted are rendered the same way.
+func Normalize(image *tinysvg.Document) []byte {
+	return append(image.CanonicalBytes(), '\n')
+}
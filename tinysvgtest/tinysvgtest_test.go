@@ -0,0 +1,31 @@
+package tinysvgtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xyproto/tinysvg"
+)
+
+func TestAssertGolden(t *testing.T) {
+	document, svg := tinysvg.NewTinySVG(64, 64)
+	svg.Describe("Golden")
+	svg.Rect2(&tinysvg.Pos{X: 1.5, Y: 2}, &tinysvg.Size{W: 10, H: 20}, tinysvg.ColorByName("red"))
+	svg.Triangle(0, 0, 10, 0, 5, 10, "blue")
+	AssertGolden(t, document, "testdata/example.svg")
+}
+
+func TestUpdate(t *testing.T) {
+	document, svg := tinysvg.NewTinySVG(8, 8)
+	svg.Box(0, 0, 4, 4, "red")
+	filename := filepath.Join(t.TempDir(), "update.svg")
+	Update = true
+	AssertGolden(t, document, filename)
+	Update = false
+	golden, err := os.ReadFile(filename)
+	if err != nil || string(golden) != string(Normalize(document)) {
+		t.Fatalf("the golden file was not written: %v\n", err)
+	}
+	AssertGolden(t, document, filename)
+}
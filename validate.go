@@ -0,0 +1,516 @@
+package tinysvg
+
+// Validation of images against the SVG Tiny 1.2 profile.
+// See: https://www.w3.org/TR/SVGTiny12/elementTable.html and https://www.w3.org/TR/SVGTiny12/attributeTable.html
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Issue is a problem found by Validate, for the element with the given path,
+// like "svg/g[2]/rect[1]". The number in brackets counts the tags with the same name, starting at 1.
+type Issue struct {
+	Path    string
+	Message string
+}
+
+// String returns the path and the message of the issue
+func (issue Issue) String() string {
+	return issue.Path + ": " + issue.Message
+}
+
+// elementSpec describes which attributes and children an element can have
+type elementSpec struct {
+	attrs    map[string]bool
+	children map[string]bool
+	text     bool // can have text content
+	foreign  bool // can have any content, like metadata
+}
+
+// Attribute groups
+const (
+	coreAttrs         = "id xml:id xml:base xml:lang xml:space class role rel rev typeof content datatype resource about property"
+	conditionalAttrs  = "requiredFeatures requiredExtensions requiredFormats requiredFonts systemLanguage"
+	propertyAttrs     = "audio-level buffered-rendering color color-rendering direction display display-align fill fill-opacity fill-rule font-family font-size font-style font-variant font-weight image-rendering line-increment opacity pointer-events shape-rendering solid-color solid-opacity stop-color stop-opacity stroke stroke-dasharray stroke-dashoffset stroke-linecap stroke-linejoin stroke-miterlimit stroke-opacity stroke-width text-align text-anchor text-rendering unicode-bidi vector-effect viewport-fill viewport-fill-opacity visibility"
+	focusAttrs        = "focusable focusHighlight nav-next nav-prev nav-up nav-up-right nav-right nav-down-right nav-down nav-down-left nav-left nav-up-left"
+	xlinkAttrs        = "xlink:href xlink:type xlink:role xlink:arcrole xlink:title xlink:show xlink:actuate"
+	timingAttrs       = "begin dur end min max restart repeatCount repeatDur fill"
+	animValueAttrs    = "calcMode values keyTimes keySplines from to by"
+	animAdditionAttrs = "additive accumulate"
+	animTargetAttrs   = "attributeName attributeType xlink:href"
+	mediaAttrs        = "syncBehavior syncTolerance syncMaster externalResourcesRequired"
+	graphicsAttrs     = coreAttrs + " " + conditionalAttrs + " " + propertyAttrs + " " + focusAttrs + " transform"
+	animationAttrs    = coreAttrs + " " + conditionalAttrs + " " + timingAttrs + " " + animValueAttrs + " " + animAdditionAttrs + " " + animTargetAttrs
+)
+
+// Content model groups
+const (
+	descriptiveElements = "desc title metadata"
+	animationElements   = "animate animateColor animateMotion animateTransform set discard"
+	shapeElements       = "path rect circle line ellipse polyline polygon"
+	graphicsElements    = "g use image switch text textArea a animation audio video foreignObject " + shapeElements
+	definitionElements  = "defs solidColor linearGradient radialGradient font font-face script handler ev:listener prefetch"
+	containerContent    = descriptiveElements + " " + animationElements + " " + graphicsElements + " " + definitionElements
+	leafContent         = descriptiveElements + " " + animationElements + " handler ev:listener prefetch"
+	textContent         = leafContent + " tspan a tbreak"
+)
+
+var (
+	tinyElements = make(map[string]*elementSpec)
+
+	// prefixes of namespaces that are part of SVG Tiny 1.2, all other prefixes are for foreign namespaces
+	tinyPrefixes = map[string]bool{"xlink": true, "xml": true, "ev": true}
+
+	// the color keywords that are allowed in SVG Tiny 1.2
+	tinyColorKeywords = map[string]bool{
+		"black": true, "silver": true, "gray": true, "white": true, "maroon": true, "red": true, "purple": true, "fuchsia": true,
+		"green": true, "lime": true, "olive": true, "yellow": true, "navy": true, "blue": true, "teal": true, "aqua": true,
+		"none": true, "currentColor": true, "inherit": true,
+	}
+
+	// attributes with a fixed set of allowed values
+	enumeratedAttrs = map[string]string{
+		"focusable":     "true false auto",
+		"text-anchor":   "start middle end inherit",
+		"text-align":    "start center end inherit",
+		"display-align": "auto before center after inherit",
+		"calcMode":      "discrete linear paced spline",
+		"additive":      "replace sum",
+		"accumulate":    "none sum",
+		"restart":       "always whenNotActive never",
+		"fill-rule":     "nonzero evenodd inherit",
+		"font-style":    "normal italic oblique inherit",
+		"font-variant":  "normal small-caps inherit",
+		"font-weight":   "normal bold bolder lighter 100 200 300 400 500 600 700 800 900 inherit",
+		"visibility":    "visible hidden collapse inherit",
+		"xml:space":     "default preserve",
+	}
+
+	coordinateAttrs = map[string]bool{
+		"x": true, "y": true, "width": true, "height": true, "rx": true, "ry": true,
+		"cx": true, "cy": true, "r": true, "x1": true, "y1": true, "x2": true, "y2": true,
+	}
+
+	// attributes that can refer to other elements with "#id"
+	linkAttrs = map[string]bool{
+		"xlink:href": true, "href": true, "handler": true,
+	}
+
+	opacityAttrs = map[string]bool{
+		"opacity": true, "fill-opacity": true, "stroke-opacity": true, "stop-opacity": true,
+		"solid-opacity": true, "viewport-fill-opacity": true,
+	}
+
+	clockValue = regexp.MustCompile(`^(\d+:)?\d+:\d{2}(\.\d+)?$|^\d+(\.\d+)?(h|min|s|ms)?$`)
+
+	transformFunction = regexp.MustCompile(`^\s*((matrix|translate|scale|rotate|skewX|skewY)\s*\([^()]*\)[\s,]*)+$|^\s*ref\s*\([^()]*\)\s*$`)
+)
+
+// defineElement adds an element to the table of SVG Tiny 1.2 elements
+func defineElement(name, attrs, children string, text bool) {
+	spec := &elementSpec{
+		attrs:    make(map[string]bool),
+		children: make(map[string]bool),
+		text:     text,
+	}
+	for _, attr := range strings.Fields(attrs) {
+		spec.attrs[attr] = true
+	}
+	for _, child := range strings.Fields(children) {
+		spec.children[child] = true
+	}
+	tinyElements[name] = spec
+}
+
+func init() {
+	defineElement("svg", graphicsAttrs+" xmlns width height viewBox preserveAspectRatio snapshotTime playbackOrder timelineBegin version baseProfile zoomAndPan contentScriptType syncBehaviorDefault syncToleranceDefault externalResourcesRequired", containerContent, false)
+	defineElement("g", graphicsAttrs+" externalResourcesRequired", containerContent, false)
+	defineElement("defs", coreAttrs+" "+propertyAttrs, containerContent, false)
+	defineElement("switch", graphicsAttrs+" externalResourcesRequired", containerContent, false)
+	defineElement("a", graphicsAttrs+" "+xlinkAttrs+" target externalResourcesRequired", containerContent+" tspan tbreak", true)
+	defineElement("use", graphicsAttrs+" "+xlinkAttrs+" x y externalResourcesRequired", leafContent, false)
+	defineElement("image", graphicsAttrs+" "+xlinkAttrs+" x y width height preserveAspectRatio type externalResourcesRequired", leafContent, false)
+	defineElement("foreignObject", graphicsAttrs+" "+xlinkAttrs+" x y width height externalResourcesRequired", "", true)
+	tinyElements["foreignObject"].foreign = true
+	for _, name := range []string{"desc", "title", "metadata"} {
+		defineElement(name, coreAttrs+" "+conditionalAttrs+" "+propertyAttrs, "", true)
+		tinyElements[name].foreign = true
+	}
+
+	defineElement("path", graphicsAttrs+" d pathLength", leafContent, false)
+	defineElement("rect", graphicsAttrs+" x y width height rx ry", leafContent, false)
+	defineElement("circle", graphicsAttrs+" cx cy r", leafContent, false)
+	defineElement("line", graphicsAttrs+" x1 y1 x2 y2", leafContent, false)
+	defineElement("ellipse", graphicsAttrs+" cx cy rx ry", leafContent, false)
+	defineElement("polyline", graphicsAttrs+" points", leafContent, false)
+	defineElement("polygon", graphicsAttrs+" points", leafContent, false)
+
+	defineElement("solidColor", coreAttrs+" "+propertyAttrs, descriptiveElements+" "+animationElements, false)
+	defineElement("linearGradient", coreAttrs+" "+propertyAttrs+" gradientUnits x1 y1 x2 y2", descriptiveElements+" "+animationElements+" stop", false)
+	defineElement("radialGradient", coreAttrs+" "+propertyAttrs+" gradientUnits cx cy r", descriptiveElements+" "+animationElements+" stop", false)
+	defineElement("stop", coreAttrs+" "+propertyAttrs+" offset", "animate animateColor set", false)
+
+	defineElement("text", graphicsAttrs+" x y editable rotate", textContent, true)
+	defineElement("tspan", coreAttrs+" "+conditionalAttrs+" "+propertyAttrs+" "+focusAttrs, textContent, true)
+	defineElement("textArea", graphicsAttrs+" x y width height editable", textContent, true)
+	defineElement("tbreak", coreAttrs+" "+conditionalAttrs, "", false)
+
+	defineElement("font", coreAttrs+" "+propertyAttrs+" horiz-origin-x horiz-adv-x externalResourcesRequired", descriptiveElements+" font-face missing-glyph glyph hkern", false)
+	defineElement("font-face", coreAttrs+" font-family font-style font-variant font-weight font-stretch font-size unicode-range units-per-em panose-1 stemv stemh slope cap-height x-height accent-height ascent descent widths bbox ideographic alphabetic mathematical hanging underline-position underline-thickness strikethrough-position strikethrough-thickness overline-position overline-thickness externalResourcesRequired", descriptiveElements+" font-face-src", false)
+	defineElement("font-face-src", coreAttrs, "font-face-uri", false)
+	defineElement("font-face-uri", coreAttrs+" "+xlinkAttrs+" externalResourcesRequired", "", false)
+	defineElement("glyph", coreAttrs+" "+propertyAttrs+" unicode glyph-name arabic-form lang horiz-adv-x d", descriptiveElements, false)
+	defineElement("missing-glyph", coreAttrs+" "+propertyAttrs+" horiz-adv-x d", descriptiveElements, false)
+	defineElement("hkern", coreAttrs+" u1 g1 u2 g2 k", "", false)
+
+	defineElement("animate", animationAttrs, descriptiveElements+" handler", false)
+	defineElement("animateColor", animationAttrs, descriptiveElements+" handler", false)
+	defineElement("animateTransform", animationAttrs+" type", descriptiveElements+" handler", false)
+	defineElement("animateMotion", coreAttrs+" "+conditionalAttrs+" "+timingAttrs+" "+animValueAttrs+" "+animAdditionAttrs+" "+xlinkAttrs+" path keyPoints rotate origin", descriptiveElements+" handler mpath", false)
+	defineElement("set", coreAttrs+" "+conditionalAttrs+" "+timingAttrs+" "+animTargetAttrs+" to", descriptiveElements+" handler", false)
+	defineElement("mpath", coreAttrs+" "+xlinkAttrs, descriptiveElements, false)
+	defineElement("discard", coreAttrs+" "+conditionalAttrs+" "+xlinkAttrs+" begin", descriptiveElements, false)
+
+	defineElement("audio", coreAttrs+" "+conditionalAttrs+" "+propertyAttrs+" "+timingAttrs+" "+mediaAttrs+" "+xlinkAttrs+" type", leafContent, false)
+	defineElement("video", graphicsAttrs+" "+timingAttrs+" "+mediaAttrs+" "+xlinkAttrs+" x y width height preserveAspectRatio type transformBehavior overlay initialVisibility", leafContent, false)
+	defineElement("animation", graphicsAttrs+" "+timingAttrs+" "+mediaAttrs+" "+xlinkAttrs+" x y width height preserveAspectRatio initialVisibility", leafContent, false)
+	defineElement("prefetch", coreAttrs+" "+xlinkAttrs+" mediaSize mediaTime mediaCharacterEncoding mediaContentEncodings bandwidth", descriptiveElements, false)
+
+	defineElement("script", coreAttrs+" "+xlinkAttrs+" type externalResourcesRequired", "", true)
+	defineElement("handler", coreAttrs+" "+xlinkAttrs+" type ev:event externalResourcesRequired", "", true)
+	defineElement("ev:listener", "id xml:id event phase propagate defaultAction observer target handler", "", false)
+}
+
+// namePrefix returns the namespace prefix of a name like "xlink:href", or "" if there is none
+func namePrefix(name string) string {
+	if colon := strings.IndexByte(name, ':'); colon > 0 {
+		return name[:colon]
+	}
+	return ""
+}
+
+// isForeign checks if an element or attribute name is from a namespace that is not part of SVG Tiny 1.2
+func isForeign(name string) bool {
+	prefix := namePrefix(name)
+	return prefix != "" && prefix != "xmlns" && !tinyPrefixes[prefix]
+}
+
+// hasText checks if a tag has text that is not whitespace, either as content, in text nodes or in CDATA sections
+func (tag *Tag) hasText() bool {
+	if len(bytes.TrimSpace(tag.content)) > 0 || len(bytes.TrimSpace(tag.lastContent)) > 0 {
+		return true
+	}
+	for child := tag.firstChild; child != nil; child = child.nextSibling {
+		if (child.kind == CDATANode || child.kind == TextNode) && len(bytes.TrimSpace(child.content)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns the path to a tag, like "svg/g[2]/rect[1]"
+func (tag *Tag) path() string {
+	var parts []string
+	for t := tag; t != nil && t.isElement(); t = t.parent {
+		if t.parent == nil || !t.parent.isElement() {
+			parts = append(parts, string(t.name))
+			break
+		}
+		n := 1
+		for sib := t.parent.firstChild; sib != t && sib != nil; sib = sib.nextSibling {
+			if sib.isElement() && string(sib.name) == string(t.name) {
+				n++
+			}
+		}
+		parts = append(parts, string(t.name)+"["+strconv.Itoa(n)+"]")
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, "/")
+}
+
+// checkChild returns a message if the given child element is not allowed in the given parent element
+func checkChild(parentName, childName string) string {
+	if isForeign(childName) {
+		return ""
+	}
+	spec, ok := tinyElements[parentName]
+	if !ok || spec.foreign {
+		return ""
+	}
+	if _, ok := tinyElements[childName]; !ok {
+		return "the " + childName + " element is not part of SVG Tiny 1.2"
+	}
+	if !spec.children[childName] {
+		return "the " + childName + " element is not allowed in the " + parentName + " element"
+	}
+	return ""
+}
+
+// checkAttrib returns a message if the given attribute or its value is not allowed for the given element
+func checkAttrib(elementName, attrName string, value []byte) string {
+	if isForeign(attrName) || isForeign(elementName) || attrName == "xmlns" || strings.HasPrefix(attrName, "xmlns:") {
+		return ""
+	}
+	spec, ok := tinyElements[elementName]
+	if !ok {
+		return ""
+	}
+	if !spec.attrs[attrName] {
+		return "the " + attrName + " attribute is not allowed in the " + elementName + " element"
+	}
+	if value == nil {
+		return "the " + attrName + " attribute has no value"
+	}
+	if problem := checkValue(elementName, attrName, strings.TrimSpace(string(value))); problem != "" {
+		return "invalid value for the " + attrName + " attribute: " + strconv.Quote(string(value)) + " (" + problem + ")"
+	}
+	return ""
+}
+
+// checkValue returns a description of what is wrong with an attribute value, or "" if it is valid
+func checkValue(elementName, attrName, value string) string {
+	if allowed, ok := enumeratedAttrs[attrName]; ok {
+		for _, word := range strings.Fields(allowed) {
+			if value == word {
+				return ""
+			}
+		}
+		return "expected one of: " + allowed
+	}
+	isAnimation := tinyElements[elementName].attrs["attributeName"] || elementName == "animateMotion"
+	switch {
+	case elementName == "svg" && attrName == "version":
+		if value != svgVersion {
+			return "expected " + svgVersion
+		}
+	case elementName == "svg" && attrName == "baseProfile":
+		if value != svgProfile {
+			return "expected " + svgProfile
+		}
+	case elementName == "svg" && (attrName == "width" || attrName == "height"):
+		if _, err := ParseLength(value); err != nil {
+			return "expected a length"
+		}
+	case elementName == "textArea" && (attrName == "width" || attrName == "height") && value == "auto":
+		return ""
+	case elementName == "text" && (attrName == "x" || attrName == "y"):
+		// A list of coordinates, one for each character
+		numbers := tokenize(value)
+		for _, number := range numbers {
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return "expected numbers"
+			}
+		}
+		if len(numbers) == 0 {
+			return "expected numbers"
+		}
+	case coordinateAttrs[attrName] && !isAnimation && elementName != "font-face":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "expected a number"
+		}
+	case opacityAttrs[attrName]:
+		if value == "inherit" {
+			return ""
+		}
+		if x, err := strconv.ParseFloat(value, 64); err != nil || x < 0 || x > 1 {
+			return "expected a number between 0 and 1"
+		}
+	case attrName == "stroke-width" || attrName == "stroke-miterlimit":
+		if value == "inherit" {
+			return ""
+		}
+		if x, err := strconv.ParseFloat(value, 64); err != nil || x < 0 {
+			return "expected a positive number"
+		}
+	case attrName == "fill" && isAnimation:
+		if value != "freeze" && value != "remove" {
+			return "expected freeze or remove"
+		}
+	case attrName == "fill" || attrName == "stroke" || attrName == "color" || attrName == "stop-color" || attrName == "solid-color" || attrName == "viewport-fill":
+		return checkPaint(value)
+	case attrName == "d" || attrName == "path":
+		if value == "" && (elementName == "glyph" || elementName == "missing-glyph") {
+			return "" // glyphs may be empty, like the glyph for space
+		}
+		return checkPathData(value)
+	case attrName == "points":
+		numbers := tokenize(value)
+		for _, number := range numbers {
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return "expected numbers"
+			}
+		}
+		if len(numbers)%2 != 0 {
+			return "expected pairs of numbers"
+		}
+	case attrName == "viewBox":
+		numbers := tokenize(value)
+		if value == "none" {
+			return ""
+		}
+		if len(numbers) != 4 {
+			return "expected four numbers"
+		}
+		for _, number := range numbers {
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return "expected four numbers"
+			}
+		}
+	case attrName == "transform":
+		if !transformFunction.MatchString(value) {
+			return "expected a transform list"
+		}
+	case attrName == "dur" || attrName == "repeatDur" || attrName == "min" || attrName == "max":
+		if value != "indefinite" && value != "media" && !clockValue.MatchString(value) {
+			return "expected a clock value"
+		}
+	case attrName == "repeatCount":
+		if value == "indefinite" {
+			return ""
+		}
+		if x, err := strconv.ParseFloat(value, 64); err != nil || x <= 0 {
+			return "expected a positive number or indefinite"
+		}
+	}
+	return ""
+}
+
+// checkPaint returns a description of what is wrong with a paint value, or "" if it is valid
+func checkPaint(value string) string {
+	if strings.HasPrefix(value, "url(") {
+		if _, ok := referencedID(value); !ok {
+			return "expected a local reference like url(#id)"
+		}
+		return ""
+	}
+	if strings.HasPrefix(value, "rgba(") {
+		return "rgba colors are not part of SVG Tiny 1.2, use fill-opacity or stroke-opacity instead"
+	}
+	c, err := ParseColor(value)
+	if err != nil {
+		return "expected a color"
+	}
+	if len(c.N) != 0 && !tinyColorKeywords[c.N] {
+		return "not one of the 16 color keywords in SVG Tiny 1.2"
+	}
+	return ""
+}
+
+// checkPathData returns a description of what is wrong with path data, or "" if it is valid
+func checkPathData(value string) string {
+	tokens := tokenize(value)
+	if len(tokens) == 0 {
+		return "expected path data"
+	}
+	for _, token := range tokens {
+		if _, err := strconv.ParseFloat(token, 64); err == nil {
+			continue
+		}
+		for _, command := range token {
+			switch {
+			case strings.ContainsRune("MmLlHhVvCcSsQqTtZz", command):
+			case command == 'A' || command == 'a':
+				return "arcs are not part of SVG Tiny 1.2"
+			default:
+				return "unexpected " + strconv.QuoteRune(command)
+			}
+		}
+	}
+	return ""
+}
+
+// references returns the ids that an attribute value of a tag refers to, with "url(#id)", "id.begin",
+// "#id" for attributes like "xlink:href" where the value is not a color, lists of ids or plain ids,
+// like for the observer attribute of ev:listener elements.
+func (tag *Tag) references(attrName string, value []byte) []string {
+	s := strings.TrimSpace(string(value))
+	if idListAttrs[attrName] || tag.isIDRef(attrName) {
+		return strings.Fields(s)
+	}
+	if id, ok := referencedID(s); ok && (strings.HasPrefix(s, "url(") || linkAttrs[attrName]) {
+		return []string{id}
+	}
+	var ids []string
+	if attrName == "begin" || attrName == "end" {
+		for _, part := range strings.Split(s, ";") {
+			part = strings.TrimSpace(part)
+			if dot := strings.IndexByte(part, '.'); dot > 0 && !strings.ContainsAny(part[:1], "+-0123456789") {
+				ids = append(ids, part[:dot])
+			}
+		}
+	}
+	for rest := s; strings.Contains(rest, "url(#"); {
+		start := strings.Index(rest, "url(#") + len("url(#")
+		end := strings.IndexByte(rest[start:], ')')
+		if end == -1 {
+			break
+		}
+		ids = append(ids, rest[start:start+end])
+		rest = rest[start+end:]
+	}
+	return ids
+}
+
+// Validate checks an image against the SVG Tiny 1.2 profile. It checks that
+// all elements and attributes are part of SVG Tiny 1.2, that elements only
+// contain the elements they are allowed to contain, that attribute values
+// have the right syntax and that all references to ids can be resolved.
+// Returns a list of issues, which is empty if the image is valid.
+func Validate(image *Document) []Issue {
+	var issues []Issue
+	top := image.topElement()
+	if top == nil || string(top.name) != svgTag {
+		return append(issues, Issue{"", "the image must have an svg root element"})
+	}
+	if string(top.Attrib("xmlns")) != xmlNS {
+		issues = append(issues, Issue{top.path(), "the xmlns attribute must be " + xmlNS})
+	}
+	ids := image.ids()
+	for id, tags := range image.DuplicateIDs() {
+		for _, t := range tags[1:] {
+			issues = append(issues, Issue{t.path(), "the id " + id + " is already in use"})
+		}
+	}
+	top.Walk(func(t *Tag, depth int) WalkAction {
+		if !t.isElement() {
+			return WalkSkipChildren
+		}
+		name := string(t.name)
+		if isForeign(name) {
+			return WalkSkipChildren
+		}
+		spec, ok := tinyElements[name]
+		if !ok {
+			issues = append(issues, Issue{t.path(), "the " + name + " element is not part of SVG Tiny 1.2"})
+			return WalkSkipChildren
+		}
+		if depth > 0 {
+			if message := checkChild(string(t.parent.name), name); message != "" {
+				issues = append(issues, Issue{t.path(), message})
+			}
+		}
+		for _, attr := range t.Attribs() {
+			if message := checkAttrib(name, attr.Name, attr.Value); message != "" {
+				issues = append(issues, Issue{t.path(), message})
+			}
+			for _, id := range t.references(attr.Name, attr.Value) {
+				if _, ok := ids[id]; !ok {
+					issues = append(issues, Issue{t.path(), "the " + attr.Name + " attribute refers to a missing id: " + id})
+				}
+			}
+		}
+		if !spec.text && t.hasText() {
+			issues = append(issues, Issue{t.path(), "text is not allowed in the " + name + " element"})
+		}
+		if spec.foreign {
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+	return issues
+}
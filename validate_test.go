@@ -0,0 +1,96 @@
+package tinysvg
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	document, svg := NewTinySVG(256, 256)
+	svg.Describe("Diagram")
+	svg.AddRoundedRect(30, 10, 5, 5, 20, 20).Fill("red")
+	svg.Triangle(0, 0, 10, 0, 5, 10, "blue")
+	svg.Line(0, 0, 10, 10, 2, "#123456")
+	g := svg.AddNewTag([]byte("g"))
+	g.AddAttrib("transform", []byte("translate(10, 10) rotate(45)"))
+	g.AddCircle(5, 5, 5).AddAttrib("fill", []byte("url(#gradient)"))
+	gradient := svg.AddNewTag([]byte("linearGradient"))
+	gradient.SetID("gradient")
+	gradient.AddNewTag([]byte("stop")).AddAttrib("offset", []byte("0"))
+	text := svg.AddNewTag([]byte("text"))
+	text.AddAttrib("x", []byte("10 20, 30"))
+	text.AddAttrib("y", []byte("40"))
+	text.AddContent([]byte("abc"))
+	if issues := Validate(document); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v\n", issues)
+	}
+
+	svg.AddNewTag([]byte("filter"))
+	rect := g.AddRect(0, 0, 10, 10)
+	rect.AddAttrib("rx", []byte("big"))
+	rect.AddAttrib("fill", []byte("steelblue"))
+	rect.AddAttrib("stroke", []byte("url(#missing)"))
+	rect.AddAttrib("clip-path", []byte("none"))
+	rect.AddNewTag([]byte("g"))
+	svg.AddNewTag([]byte("path")).AddAttrib("d", []byte("M 0 0 A 5 5 0 0 1 10 10"))
+	svg.AddNewTag([]byte("metadata")).AddNewTag([]byte("rdf:RDF"))
+	text.AddAttrib("y", []byte("40 big"))
+
+	expected := []string{
+		"svg/filter[1]: the filter element is not part of SVG Tiny 1.2",
+		"svg/g[1]/rect[1]: the clip-path attribute is not allowed in the rect element",
+		"svg/g[1]/rect[1]: invalid value for the fill attribute",
+		"svg/g[1]/rect[1]: invalid value for the rx attribute",
+		"svg/g[1]/rect[1]: the stroke attribute refers to a missing id: missing",
+		"svg/g[1]/rect[1]/g[1]: the g element is not allowed in the rect element",
+		"svg/path[2]: invalid value for the d attribute",
+		"svg/text[1]: invalid value for the y attribute",
+	}
+	issues := Validate(document)
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d: %v\n", len(expected), len(issues), issues)
+	}
+	for _, prefix := range expected {
+		found := false
+		for _, issue := range issues {
+			if strings.HasPrefix(issue.String(), prefix) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected an issue starting with %q, got %v\n", prefix, issues)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	document, svg := NewTinySVG(100, 100)
+	document.SetStrict(true)
+	svg.Box(0, 0, 10, 10, "red")
+	if errs := document.Errors(); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v\n", errs)
+	}
+	svg.AddNewTag([]byte("filter"))
+	_, _, line, _ := runtime.Caller(0)
+	rect := svg.AddRect(0, 0, 10, 10)
+	rect.AddAttrib("clip-path", []byte("none"))
+	rect.Fill("steelblue")
+
+	errs := document.Errors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v\n", errs)
+	}
+	e, ok := errs[0].(*StrictError)
+	if !ok || !strings.HasSuffix(e.File, "validate_test.go") || e.Line != line-1 || e.Path != "svg/filter[1]" {
+		t.Fatalf("unexpected error: %v\n", errs[0])
+	}
+
+	// Tags that are built separately are checked when they are added
+	g := NewTag([]byte("g"))
+	g.AddNewTag([]byte("pattern"))
+	svg.AddChild(g)
+	if len(document.Errors()) != 4 {
+		t.Fatalf("expected 4 errors, got %v\n", document.Errors())
+	}
+}
@@ -0,0 +1,111 @@
+package tinysvg
+
+// WalkAction is returned by the functions given to Walk and WalkPostOrder,
+// to decide how the walk should proceed.
+type WalkAction int
+
+const (
+	// WalkContinue continues the walk as usual
+	WalkContinue WalkAction = iota
+	// WalkSkipChildren continues the walk, but does not visit the children of the current tag.
+	// For WalkPostOrder, the children have already been visited, so this is the same as WalkContinue.
+	WalkSkipChildren
+	// WalkStop stops the walk
+	WalkStop
+)
+
+// Iterator iterates over the descendants of a tag, depth first and in document order
+type Iterator struct {
+	root *Tag
+	next *Tag
+}
+
+// Walk calls f for a tag and all its descendants, depth first and in document order.
+// The depth of the tag that Walk is called on is 0.
+// Returns false if the walk was stopped by f returning WalkStop.
+func (tag *Tag) Walk(f func(t *Tag, depth int) WalkAction) bool {
+	return tag.walk(f, 0)
+}
+
+func (tag *Tag) walk(f func(t *Tag, depth int) WalkAction, depth int) bool {
+	switch f(tag, depth) {
+	case WalkStop:
+		return false
+	case WalkSkipChildren:
+		return true
+	}
+	for child := tag.firstChild; child != nil; {
+		// Fetch the next sibling first, in case f removes or moves the child
+		next := child.nextSibling
+		if !child.walk(f, depth+1) {
+			return false
+		}
+		child = next
+	}
+	return true
+}
+
+// WalkPostOrder calls f for a tag and all its descendants, depth first,
+// but visits the children of a tag before the tag itself.
+// The depth of the tag that WalkPostOrder is called on is 0.
+// Returns false if the walk was stopped by f returning WalkStop.
+func (tag *Tag) WalkPostOrder(f func(t *Tag, depth int) WalkAction) bool {
+	return tag.walkPostOrder(f, 0)
+}
+
+func (tag *Tag) walkPostOrder(f func(t *Tag, depth int) WalkAction, depth int) bool {
+	for child := tag.firstChild; child != nil; {
+		next := child.nextSibling
+		if !child.walkPostOrder(f, depth+1) {
+			return false
+		}
+		child = next
+	}
+	return f(tag, depth) != WalkStop
+}
+
+// Descendants returns an Iterator over all descendants of a tag,
+// not including the tag itself. The tree should not be modified while iterating.
+//
+//	it := tag.Descendants()
+//	for t := it.Next(); t != nil; t = it.Next() {
+//		...
+//	}
+func (tag *Tag) Descendants() *Iterator {
+	return &Iterator{root: tag, next: tag.firstChild}
+}
+
+// Next returns the next tag, or nil when there are no more tags
+func (it *Iterator) Next() *Tag {
+	current := it.next
+	if current == nil {
+		return nil
+	}
+	// Find the tag after the current one
+	if current.firstChild != nil {
+		it.next = current.firstChild
+		return current
+	}
+	t := current
+	for t != nil && t != it.root && t.nextSibling == nil {
+		t = t.parent
+	}
+	if t == it.root || t == nil {
+		it.next = nil
+	} else {
+		it.next = t.nextSibling
+	}
+	return current
+}
+
+// Walk calls f for all tags in the image, depth first and in document order.
+// The tags right below the root tag of the document, like the svg tag, have depth 0.
+// Returns false if the walk was stopped by f returning WalkStop.
+func (image *Document) Walk(f func(t *Tag, depth int) WalkAction) bool {
+	for child := image.root.firstChild; child != nil; child = child.nextSibling {
+		if !child.Walk(f) {
+			return false
+		}
+	}
+	return true
+}